@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/fukaraca/runesmith/shared"
 	logg "github.com/fukaraca/runesmith/shared/log"
 	"github.com/spf13/viper"
@@ -33,9 +34,19 @@ func (c *Config) Load(filename, path string) error {
 	v.SetConfigType("yml")
 
 	v.SetDefault("kubelet.socketPath", v1beta1.DevicePluginPath+v1beta1.KubeletSocket)
+	v.SetDefault("kubelet.podResourcesSocket", "/var/lib/kubelet/pod-resources/kubelet.sock")
+	v.SetDefault("kubelet.checkpointPath", v1beta1.DevicePluginPath+"kubelet_internal_checkpoint")
 	v.SetDefault("server.socketPath", v1beta1.DevicePluginPath+"manawell.sock")
 	v.SetDefault("mana.maxMana", 100)
+	v.SetDefault("mana.wellSize", 10)
+	v.SetDefault("watcher.reconcileInterval", 30*time.Second)
+	v.SetDefault("watcher.staleAllocationAge", 10*time.Minute)
+	v.SetDefault("watcher.unmappedGracePeriod", 2*time.Minute)
 	v.SetDefault("node.namespace", "default")
+	v.SetDefault("health.checkInterval", 10*time.Second)
+	v.SetDefault("health.overheatThreshold", 0.8)
+	v.SetDefault("health.overheatAfter", 5*time.Minute)
+	v.SetDefault("health.cooldown", 2*time.Minute)
 	v.AllowEmptyEnv(true)
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
@@ -55,9 +66,25 @@ func (c *Config) Load(filename, path string) error {
 		return err
 	}
 	c.Mana.ResourceName = fmt.Sprintf("manawell.io/%s", c.Mana.EnergyType)
+	c.v = v
 	return nil
 }
 
+// Watch calls onChange with the reloaded config whenever the file changes, including the symlink swap of a
+// mounted ConfigMap. A file that fails to parse is skipped.
+func (c *Config) Watch(onChange func(*Config, error)) {
+	c.v.OnConfigChange(func(fsnotify.Event) {
+		reloaded := NewConfig()
+		if err := c.v.Unmarshal(reloaded); err != nil {
+			onChange(nil, err)
+			return
+		}
+		reloaded.Mana.ResourceName = fmt.Sprintf("manawell.io/%s", reloaded.Mana.EnergyType)
+		onChange(reloaded, nil)
+	})
+	c.v.WatchConfig()
+}
+
 type Config struct {
 	Server     ServerConfig     `mapstructure:"server"`
 	Mana       ManaConfig       `mapstructure:"mana"`
@@ -66,6 +93,9 @@ type Config struct {
 	Log        logg.Config      `mapstructure:"log"`
 	Watcher    WatcherConfig    `mapstructure:"watcher"`
 	Node       NodeConfig       `mapstructure:"node"`
+	Health     HealthConfig     `mapstructure:"health"`
+
+	v *viper.Viper // kept to watch the file for Watch
 }
 
 type ServerConfig struct {
@@ -79,15 +109,25 @@ type ManaConfig struct {
 	MaxMana      int              `mapstructure:"maxMana"`
 	EnergyType   shared.Elemental `mapstructure:"energyType"`
 	ResourceName string           `mapstructure:"resourceName"`
+	// WellSize groups the devices into wells of consecutive IDs, allocations are packed into as few wells as
+	// possible. Zero puts all of them into one well.
+	WellSize int `mapstructure:"wellSize"`
+	// ReportWellTopology advertises the well of a device to the kubelet as its NUMA node
+	ReportWellTopology bool `mapstructure:"reportWellTopology"`
 }
 
 type MonitoringConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
 	MetricsPort    int           `mapstructure:"metricsPort"`
 	UpdateInterval time.Duration `mapstructure:"updateInterval"`
 }
 
 type KubeletConfig struct {
-	SocketPath      string        `mapstructure:"socketPath"`
+	SocketPath string `mapstructure:"socketPath"`
+	// PodResourcesSocket serves the PodResources API, the devices running pods hold are restored from it
+	PodResourcesSocket string `mapstructure:"podResourcesSocket"`
+	// CheckpointPath is the device manager checkpoint of the kubelet, read when PodResources is unavailable
+	CheckpointPath  string        `mapstructure:"checkpointPath"`
 	RetryAttempts   int           `mapstructure:"retryAttempts"`
 	BackoffInterval time.Duration `mapstructure:"backoffInterval"`
 }
@@ -95,6 +135,24 @@ type KubeletConfig struct {
 type WatcherConfig struct {
 	ResyncInterval      time.Duration `mapstructure:"resyncInterval"`
 	SocketCheckInterval time.Duration `mapstructure:"socketCheckInterval"`
+	// ReconcileInterval is how often allocations are diffed against the live pods, zero turns it off
+	ReconcileInterval time.Duration `mapstructure:"reconcileInterval"`
+	// StaleAllocationAge is how old an allocation without a pod gets before it is flagged
+	StaleAllocationAge time.Duration `mapstructure:"staleAllocationAge"`
+	// UnmappedGracePeriod is how long a device handed out by Allocate may stay without a pod holding it
+	UnmappedGracePeriod time.Duration `mapstructure:"unmappedGracePeriod"`
+}
+
+// HealthConfig simulates wells overheating under sustained use, shrinking the capacity the kubelet sees
+type HealthConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	CheckInterval time.Duration `mapstructure:"checkInterval"`
+	// OverheatThreshold is the share of a well in use that heats it up
+	OverheatThreshold float64 `mapstructure:"overheatThreshold"`
+	// OverheatAfter is how long a well is used at the threshold or above before its devices turn Unhealthy
+	OverheatAfter time.Duration `mapstructure:"overheatAfter"`
+	// Cooldown is how long an overheated well stays Unhealthy
+	Cooldown time.Duration `mapstructure:"cooldown"`
 }
 
 type NodeConfig struct {
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+var unhealthyMana = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "manawell_unhealthy_mana",
+	Help: "Mana devices of overheated wells that are cooling down",
+})
+
+// wellHeat tracks how long a well has been drawn on and how long it cools down
+type wellHeat struct {
+	hotSince  time.Time // zero while the well is used below the threshold
+	coolUntil time.Time // zero unless the well overheated
+}
+
+func (h *wellHeat) overheated() bool {
+	return !h.coolUntil.IsZero()
+}
+
+// CheckHealth heats up the wells. A well used at OverheatThreshold or above for OverheatAfter overheats, all its
+// devices turn Unhealthy until Cooldown passed. The devices a pod holds keep running, the kubelet just won't
+// hand them out again. It returns the wells that overheated and the ones that recovered.
+func (m *ManaGer) CheckHealth(now time.Time, cfg HealthConfig) (overheated, recovered []int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	size := make(map[int]int)
+	for _, well := range m.wells {
+		size[well]++
+	}
+	inUse := make(map[int]int)
+	for well, n := range size {
+		inUse[well] = n
+	}
+	for _, id := range m.freeIDs {
+		inUse[m.wells[id]]--
+	}
+
+	for well, n := range size {
+		h, ok := m.heat[well]
+		if !ok {
+			h = &wellHeat{}
+			m.heat[well] = h
+		}
+		switch {
+		case h.overheated():
+			if now.Before(h.coolUntil) {
+				continue
+			}
+			*h = wellHeat{}
+			recovered = append(recovered, well)
+		case float64(inUse[well]) < cfg.OverheatThreshold*float64(n):
+			h.hotSince = time.Time{}
+		case h.hotSince.IsZero():
+			h.hotSince = now
+		case now.Sub(h.hotSince) >= cfg.OverheatAfter:
+			h.coolUntil = now.Add(cfg.Cooldown)
+			overheated = append(overheated, well)
+		}
+	}
+	if len(overheated) == 0 && len(recovered) == 0 {
+		return nil, nil
+	}
+	slices.Sort(overheated)
+	slices.Sort(recovered)
+
+	var unhealthy int
+	for _, dev := range m.allDevices {
+		dev.Health = pluginapi.Healthy
+		if m.heat[m.wells[dev.ID]].overheated() {
+			dev.Health = pluginapi.Unhealthy
+			unhealthy++
+		}
+	}
+	unhealthyMana.Set(float64(unhealthy))
+	m.notify()
+	return overheated, recovered
+}
+
+// healthLoop checks the wells every CheckInterval until the plugin stops, changes reach the kubelet through
+// ListAndWatch
+func (p *DevicePlugin) healthLoop(ctx context.Context, stopCh <-chan bool) {
+	cfg := p.config.Health
+	if !cfg.Enabled || cfg.CheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			overheated, recovered := p.manager.CheckHealth(now, cfg)
+			for _, well := range overheated {
+				p.logger.Warn("well overheated", slog.Int("well", well), slog.Time("until", now.Add(cfg.Cooldown)))
+			}
+			for _, well := range recovered {
+				p.logger.Info("well cooled down", slog.Int("well", well))
+			}
+		}
+	}
+}
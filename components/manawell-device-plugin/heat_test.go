@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	"google.golang.org/grpc"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+var testHealth = HealthConfig{
+	Enabled:           true,
+	OverheatThreshold: 0.5,
+	OverheatAfter:     time.Minute,
+	Cooldown:          2 * time.Minute,
+}
+
+func unhealthyIDs(m *ManaGer) []string {
+	var ids []string
+	for _, dev := range m.GetAllDevices() {
+		if dev.Health == pluginapi.Unhealthy {
+			ids = append(ids, dev.ID)
+		}
+	}
+	return ids
+}
+
+func TestCheckHealthOverheatsAndCoolsDown(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy, WellSize: 2})
+	if err := m.AllocateDevices([]string{"fire-003"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	start := time.Unix(1000, 0)
+
+	if overheated, _ := m.CheckHealth(start, testHealth); overheated != nil {
+		t.Fatalf("well overheated on first use: %v", overheated)
+	}
+	if overheated, _ := m.CheckHealth(start.Add(30*time.Second), testHealth); overheated != nil {
+		t.Fatalf("well overheated before OverheatAfter: %v", overheated)
+	}
+	changed := m.Changed()
+	overheated, _ := m.CheckHealth(start.Add(time.Minute), testHealth)
+	if !slices.Equal(overheated, []int{1}) {
+		t.Fatalf("overheated = %v, want well 1", overheated)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Error("overheating didn't notify the watchers")
+	}
+	if got := unhealthyIDs(m); !slices.Equal(got, []string{"fire-003", "fire-004"}) {
+		t.Errorf("unhealthy = %v, want the devices of well 1", got)
+	}
+
+	// releasing doesn't cut the cooldown short
+	m.MapAllocations("uid-blade", "blade", "default", []string{"fire-003"}, 1)
+	if err := m.ReleaseDevices("uid-blade"); err != nil {
+		t.Fatalf("ReleaseDevices: %v", err)
+	}
+	if _, recovered := m.CheckHealth(start.Add(2*time.Minute), testHealth); recovered != nil {
+		t.Fatalf("recovered before the cooldown: %v", recovered)
+	}
+	_, recovered := m.CheckHealth(start.Add(3*time.Minute), testHealth)
+	if !slices.Equal(recovered, []int{1}) {
+		t.Fatalf("recovered = %v, want well 1", recovered)
+	}
+	if got := unhealthyIDs(m); got != nil {
+		t.Errorf("unhealthy after the cooldown = %v", got)
+	}
+}
+
+func TestCheckHealthResetsWhenUseDrops(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 2, EnergyType: shared.FireEnergy, WellSize: 2})
+	if err := m.AllocateDevices([]string{"fire-001"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	m.MapAllocations("uid-blade", "blade", "default", []string{"fire-001"}, 1)
+	start := time.Unix(1000, 0)
+	m.CheckHealth(start, testHealth)
+
+	if err := m.ReleaseDevices("uid-blade"); err != nil {
+		t.Fatalf("ReleaseDevices: %v", err)
+	}
+	m.CheckHealth(start.Add(30*time.Second), testHealth)
+	if err := m.AllocateDevices([]string{"fire-002"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	m.CheckHealth(start.Add(40*time.Second), testHealth)
+	if overheated, _ := m.CheckHealth(start.Add(time.Minute), testHealth); overheated != nil {
+		t.Errorf("well overheated although its use dropped in between: %v", overheated)
+	}
+}
+
+func TestResize(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy, WellSize: 2})
+	if err := m.AllocateDevices([]string{"fire-004"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	m.MapAllocations("uid-blade", "blade", "default", []string{"fire-004"}, 1)
+
+	changed := m.Changed()
+	m.Resize(2)
+	select {
+	case <-changed:
+	default:
+		t.Error("shrinking didn't notify the watchers")
+	}
+	if got := len(m.GetAllDevices()); got != 2 {
+		t.Fatalf("devices = %d, want 2", got)
+	}
+	if got := m.GetAvailableMana(); got != 2 {
+		t.Errorf("available mana = %d, want 2", got)
+	}
+	if err := m.ReleaseDevices("uid-blade"); err != nil {
+		t.Fatalf("ReleaseDevices: %v", err)
+	}
+	if got := m.GetAvailableMana(); got != 2 {
+		t.Errorf("a dropped device was freed, available mana = %d, want 2", got)
+	}
+
+	m.Resize(5)
+	devices := m.GetAllDevices()
+	if got := devices[len(devices)-1].ID; len(devices) != 5 || got != "fire-005" {
+		t.Fatalf("unexpected devices after growing, last = %s of %d", got, len(devices))
+	}
+	if got := m.GetAvailableMana(); got != 5 {
+		t.Errorf("available mana = %d, want 5", got)
+	}
+}
+
+func TestResizeKeepsHeldDeviceTaken(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy, WellSize: 2})
+	if err := m.AllocateDevices([]string{"fire-004"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	m.MapAllocations("uid-blade", "blade", "default", []string{"fire-004"}, 1)
+
+	m.Resize(2)
+	m.Resize(4)
+	if got := m.GetAvailableMana(); got != 3 {
+		t.Fatalf("available mana after growing back = %d, want 3 while fire-004 is held", got)
+	}
+	if err := m.ReleaseDevices("uid-blade"); err != nil {
+		t.Fatalf("ReleaseDevices: %v", err)
+	}
+	if got := m.GetAvailableMana(); got != 4 {
+		t.Errorf("available mana after release = %d, want 4", got)
+	}
+
+	m.mutex.Lock()
+	m.free("fire-004") // freed again by a late release
+	m.mutex.Unlock()
+	if got := m.GetAvailableMana(); got != 4 {
+		t.Errorf("available mana = %d, want no more than the 4 devices served", got)
+	}
+}
+
+// fakeListAndWatch collects what ListAndWatch sends to the kubelet
+type fakeListAndWatch struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan []*pluginapi.Device
+}
+
+func (f *fakeListAndWatch) Send(resp *pluginapi.ListAndWatchResponse) error {
+	f.sent <- resp.Devices
+	return nil
+}
+
+func (f *fakeListAndWatch) Context() context.Context {
+	return f.ctx
+}
+
+func TestListAndWatchPushesChanges(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 2, EnergyType: shared.FireEnergy})
+	p := &DevicePlugin{
+		manager: m,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		stopCh:  make(chan bool),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeListAndWatch{ctx: ctx, sent: make(chan []*pluginapi.Device, 1)}
+	done := make(chan error)
+	go func() { done <- p.ListAndWatch(&pluginapi.Empty{}, stream) }()
+
+	next := func() []*pluginapi.Device {
+		t.Helper()
+		select {
+		case devices := <-stream.sent:
+			return devices
+		case <-time.After(time.Second):
+			t.Fatal("nothing was sent to the kubelet")
+			return nil
+		}
+	}
+	if got := len(next()); got != 2 {
+		t.Fatalf("initial devices = %d, want 2", got)
+	}
+	m.Resize(3)
+	if got := len(next()); got != 3 {
+		t.Errorf("devices after resize = %d, want 3", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("ListAndWatch: %v", err)
+	}
+}
+
+func TestListAndWatchEndsWithItsRun(t *testing.T) {
+	p := &DevicePlugin{
+		manager: NewManaGer(ManaConfig{MaxMana: 2, EnergyType: shared.FireEnergy}),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		stopCh:  make(chan bool),
+	}
+	stream := &fakeListAndWatch{ctx: context.Background(), sent: make(chan []*pluginapi.Device, 1)}
+	done := make(chan error)
+	go func() { done <- p.ListAndWatch(&pluginapi.Empty{}, stream) }()
+	<-stream.sent
+
+	// a restart stops the old run and starts a new one while the stream is still open
+	p.mu.Lock()
+	old := p.stopCh
+	p.stopCh = make(chan bool)
+	p.mu.Unlock()
+	close(old)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListAndWatch: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream outlived the run it was opened in")
+	}
+}
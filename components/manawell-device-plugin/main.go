@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -63,10 +64,25 @@ func runPlugin() error {
 
 	manager := NewManaGer(config.Mana)
 
-	//	metricsServer := NewMetricsServer(config.Monitoring.MetricsPort, manager)
-	//	go metricsServer.Start()
+	if config.Monitoring.Enabled {
+		metricsServer := NewMetricsServer(config.Monitoring, manager)
+		go metricsServer.Start()
+		defer metricsServer.Stop()
+	}
 
 	plugin := NewManaDevicePlugin(config, manager)
+	config.Watch(func(reloaded *Config, err error) {
+		if err != nil {
+			plugin.logger.Warn("failed to reload config", slog.Any("error", err))
+			return
+		}
+		if reloaded.Mana.MaxMana == manager.GetMaxMana() {
+			return
+		}
+		manager.Resize(reloaded.Mana.MaxMana) // only maxMana is applied without a restart
+		plugin.logger.Info("resized mana", slog.Int("maxMana", reloaded.Mana.MaxMana),
+			slog.Int("allocated", manager.GetAllocatedMana()), slog.Int("available", manager.GetAvailableMana()))
+	})
 
 	go func() {
 		if err := plugin.Start(ctx); err != nil {
@@ -3,39 +3,138 @@ package main
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/fukaraca/runesmith/shared"
 	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 )
 
 type ManaGer struct {
-	mutex       sync.RWMutex
-	maxMana     int
-	energyType  shared.Elemental
-	freeIDs     []string
-	allDevices  []*pluginapi.Device // in our case we won't encounter an unhealthy device, so no need to keep track of it
-	allocations map[string]shared.AllocationInfo
+	mutex          sync.RWMutex
+	maxMana        int
+	energyType     shared.Elemental
+	wellSize       int
+	reportTopology bool
+	freeIDs        []string
+	allDevices     []*pluginapi.Device
+	allocations    map[string]shared.AllocationInfo
+	allocatedAt    map[string]time.Time // when Allocate handed out a device, until it is free again
+	wells          map[string]int       // well of every device, see ManaConfig.WellSize
+	changed        chan struct{}        // closed and replaced whenever allDevices changes
+	heat           map[int]*wellHeat
 }
 
 func NewManaGer(cfg ManaConfig) *ManaGer {
-	freeIDs := make([]string, cfg.MaxMana)
-	allDevices := make([]*pluginapi.Device, cfg.MaxMana)
-	for i := 1; i <= cfg.MaxMana; i++ { // TODO what to do on a restart ...
-		id := fmt.Sprintf("%s-%03d", cfg.EnergyType, i)
-		freeIDs[i-1] = id
-		allDevices[i-1] = &pluginapi.Device{
+	m := &ManaGer{
+		energyType:     cfg.EnergyType,
+		wellSize:       cfg.WellSize,
+		reportTopology: cfg.ReportWellTopology,
+		allocations:    make(map[string]shared.AllocationInfo),
+		allocatedAt:    make(map[string]time.Time),
+		wells:          make(map[string]int, cfg.MaxMana),
+		changed:        make(chan struct{}),
+		heat:           make(map[int]*wellHeat),
+	}
+	m.grow(cfg.MaxMana) // allocations of a previous run are restored by RestoreAllocations
+	return m
+}
+
+// grow adds devices up to maxMana, the caller holds the lock. A device dropped by an earlier shrink that a
+// pod still holds comes back taken, its release frees it.
+func (m *ManaGer) grow(maxMana int) {
+	held := make(map[string]bool, len(m.allocatedAt))
+	for id := range m.allocatedAt {
+		held[id] = true
+	}
+	for _, allocation := range m.allocations {
+		for _, id := range allocation.DeviceIDs {
+			held[id] = true
+		}
+	}
+	for i := m.maxMana + 1; i <= maxMana; i++ {
+		id := fmt.Sprintf("%s-%03d", m.energyType, i)
+		well := 0
+		if m.wellSize > 0 {
+			well = (i - 1) / m.wellSize
+		}
+		dev := &pluginapi.Device{
 			ID:     id,
 			Health: pluginapi.Healthy,
 		}
+		if h, ok := m.heat[well]; ok && h.overheated() {
+			dev.Health = pluginapi.Unhealthy // joins a well cooling down
+		}
+		if m.reportTopology {
+			dev.Topology = &pluginapi.TopologyInfo{Nodes: []*pluginapi.NUMANode{{ID: int64(well)}}}
+		}
+		m.wells[id] = well
+		if !held[id] {
+			m.freeIDs = append(m.freeIDs, id)
+		}
+		m.allDevices = append(m.allDevices, dev)
+	}
+	m.maxMana = max(m.maxMana, maxMana)
+}
+
+// Resize changes the number of devices we serve. Shrinking drops the highest IDs, the ones a pod still holds
+// stay with its allocation but aren't freed again.
+func (m *ManaGer) Resize(maxMana int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if maxMana == m.maxMana || maxMana < 0 {
+		return
+	}
+	if maxMana > m.maxMana {
+		m.grow(maxMana)
+		m.notify()
+		return
+	}
+
+	for _, dev := range m.allDevices[maxMana:] {
+		delete(m.wells, dev.ID)
+	}
+	m.allDevices = m.allDevices[:maxMana:maxMana]
+	free := m.freeIDs[:0]
+	for _, id := range m.freeIDs {
+		if _, ok := m.wells[id]; ok {
+			free = append(free, id)
+		}
 	}
+	m.freeIDs = free
+	m.maxMana = maxMana
+	m.notify()
+}
+
+func (m *ManaGer) GetMaxMana() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.maxMana
+}
+
+// Changed is closed on the next change of the devices, get it before GetAllDevices to not miss one
+func (m *ManaGer) Changed() <-chan struct{} {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.changed
+}
 
-	return &ManaGer{
-		maxMana:     cfg.MaxMana,
-		energyType:  cfg.EnergyType,
-		freeIDs:     freeIDs,
-		allDevices:  allDevices,
-		allocations: make(map[string]shared.AllocationInfo),
+// notify wakes up everyone waiting on Changed, the caller holds the lock
+func (m *ManaGer) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// free gives the devices back unless we stopped serving them or they are free already, the caller holds
+// the lock
+func (m *ManaGer) free(ids ...string) {
+	for _, id := range ids {
+		if _, ok := m.wells[id]; ok && !slices.Contains(m.freeIDs, id) {
+			m.freeIDs = append(m.freeIDs, id)
+		}
+		delete(m.allocatedAt, id)
 	}
 }
 
@@ -46,45 +145,100 @@ func (m *ManaGer) GetAvailableMana() int {
 }
 
 func (m *ManaGer) GetAllocatedMana() int {
-	return m.maxMana - m.GetAvailableMana()
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.maxMana - len(m.freeIDs)
 }
 
+// GetAllDevices returns a snapshot of the devices, their health changes underneath
 func (m *ManaGer) GetAllDevices() []*pluginapi.Device {
-	return m.allDevices
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	devices := make([]*pluginapi.Device, len(m.allDevices))
+	for i, dev := range m.allDevices {
+		devices[i] = &pluginapi.Device{ID: dev.ID, Health: dev.Health, Topology: dev.Topology}
+	}
+	return devices
 }
 
-// AllocateDevices is called on  Allocate() by kubelet. We still don't know which pod took which devices
-func (m *ManaGer) AllocateDevices(count int) ([]string, error) {
-	if count <= 0 {
-		return nil, errors.New("count must be > 0")
+// AllocateDevices is called on Allocate() by kubelet with the devices it picked. The kubelet is the authority,
+// a device we thought taken is handed out anyway so that both sides count the same. We still don't know which
+// pod took which devices.
+func (m *ManaGer) AllocateDevices(ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("no devices requested")
 	}
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if len(m.freeIDs) < count {
-		return nil, errors.New("insufficient mana")
+	picked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := m.wells[id]; !ok {
+			return fmt.Errorf("unknown device %s", id)
+		}
+		picked[id] = true
 	}
-
-	allocatedIDs := make([]string, count)
-	for i := 0; i < count; i++ {
-		allocatedIDs[i] = m.freeIDs[len(m.freeIDs)-1]
-		m.freeIDs = m.freeIDs[:len(m.freeIDs)-1]
+	now := time.Now()
+	for id := range picked {
+		m.allocatedAt[id] = now
 	}
-
-	return allocatedIDs, nil
+	free := m.freeIDs[:0]
+	for _, id := range m.freeIDs {
+		if !picked[id] {
+			free = append(free, id)
+		}
+	}
+	m.freeIDs = free
+	return nil
 }
 
-// MapAllocations maps devices after pods report themselves
+// MapAllocations maps the devices a pod holds, as the kubelet reports them or the pod reports itself. Mapping
+// a pod again replaces its devices, the ones it no longer holds are freed. A device the kubelet handed on is
+// taken from the pod we still had it mapped to, a missed release must not free it later.
 func (m *ManaGer) MapAllocations(podID, podName, namespace string, deviceIDs []string, timestamp int64) {
 	if len(deviceIDs) == 0 {
 		return
 	}
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	if v, ok := m.allocations[podID]; ok { // maybe same pod reported again, retry or double container scenarios.
-		delete(m.allocations, podID)
-		m.freeIDs = append(m.freeIDs, v.DeviceIDs...)
+
+	held := make(map[string]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		held[id] = true
 	}
+	if v, ok := m.allocations[podID]; ok { // reported again by the kubelet, a retry or another container
+		for _, id := range v.DeviceIDs {
+			if !held[id] {
+				m.free(id)
+			}
+		}
+	}
+	for uid, other := range m.allocations {
+		if uid == podID || !holdsAny(held, other.DeviceIDs) {
+			continue
+		}
+		kept := make([]string, 0, len(other.DeviceIDs))
+		for _, id := range other.DeviceIDs {
+			if !held[id] {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m.allocations, uid)
+			continue
+		}
+		other.DeviceIDs = kept
+		m.allocations[uid] = other
+	}
+	// the kubelet is the authority, a held device is never free whatever Allocate did
+	free := m.freeIDs[:0]
+	for _, id := range m.freeIDs {
+		if !held[id] {
+			free = append(free, id)
+		}
+	}
+	m.freeIDs = free
 
 	m.allocations[podID] = shared.AllocationInfo{
 		PodUID:    podID,
@@ -95,6 +249,78 @@ func (m *ManaGer) MapAllocations(podID, podName, namespace string, deviceIDs []s
 	}
 }
 
+// RestoreAllocations replaces the allocations with the ones the kubelet reports, every device they don't hold
+// is free. It returns how many reported devices we don't serve, they are left out.
+func (m *ManaGer) RestoreAllocations(allocations []shared.AllocationInfo) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	known := make(map[string]bool, len(m.allDevices))
+	for _, dev := range m.allDevices {
+		known[dev.ID] = true
+	}
+	inUse := make(map[string]bool)
+	var unknown int
+	m.allocations = make(map[string]shared.AllocationInfo, len(allocations))
+	for _, allocation := range allocations {
+		ids := make([]string, 0, len(allocation.DeviceIDs))
+		for _, id := range allocation.DeviceIDs {
+			if !known[id] {
+				unknown++
+				continue
+			}
+			if !inUse[id] {
+				inUse[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		allocation.DeviceIDs = ids
+		m.allocations[allocation.PodUID] = allocation
+	}
+
+	m.freeIDs = m.freeIDs[:0]
+	for _, dev := range m.allDevices {
+		if !inUse[dev.ID] {
+			m.freeIDs = append(m.freeIDs, dev.ID)
+		}
+	}
+	return unknown
+}
+
+// ReleaseUnmapped frees the devices taken by Allocate that no allocation maps and the kubelet doesn't hold
+// either, once grace passed since they were handed out. The pod they were meant for never got to hold them.
+// It returns the freed devices.
+func (m *ManaGer) ReleaseUnmapped(held map[string]bool, now time.Time, grace time.Duration) []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	taken := make(map[string]bool, len(m.wells))
+	for id := range m.wells {
+		taken[id] = true
+	}
+	for _, id := range m.freeIDs {
+		delete(taken, id)
+	}
+	for _, allocation := range m.allocations {
+		for _, id := range allocation.DeviceIDs {
+			delete(taken, id)
+		}
+	}
+	var released []string
+	for id := range taken {
+		if held[id] || now.Sub(m.allocatedAt[id]) < grace {
+			continue
+		}
+		released = append(released, id)
+	}
+	slices.Sort(released)
+	m.free(released...)
+	return released
+}
+
 func (m *ManaGer) ReleaseDevices(podUID string) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -104,7 +330,7 @@ func (m *ManaGer) ReleaseDevices(podUID string) error {
 		return fmt.Errorf("no allocation found for pod UID: %s", podUID)
 	}
 
-	m.freeIDs = append(m.freeIDs, allocation.DeviceIDs...)
+	m.free(allocation.DeviceIDs...)
 	delete(m.allocations, podUID)
 
 	return nil
@@ -117,6 +343,12 @@ func (m *ManaGer) GetAllocation(podUID string) (shared.AllocationInfo, bool) {
 	return allocation, exists
 }
 
+func (m *ManaGer) GetAllocationCount() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.allocations)
+}
+
 func (m *ManaGer) GetAllAllocations() map[string]shared.AllocationInfo {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
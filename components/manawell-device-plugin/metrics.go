@@ -10,8 +10,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// TODO not sure if i am gonna use this but lets keep it
-
 type MetricsServer struct {
 	port           int
 	updateInterval time.Duration
@@ -37,13 +35,15 @@ func NewMetricsServer(config MonitoringConfig, manager *ManaGer) *MetricsServer
 
 	registry.MustRegister(manaGauge)
 	registry.MustRegister(allocGauge)
+	registry.MustRegister(orphanedReleased, orphanedMana, unmappedAdopted, staleAllocations, unhealthyMana)
 
 	return &MetricsServer{
-		port:       config.MetricsPort,
-		manager:    manager,
-		manaGauge:  manaGauge,
-		allocGauge: allocGauge,
-		registry:   registry,
+		port:           config.MetricsPort,
+		updateInterval: config.UpdateInterval,
+		manager:        manager,
+		manaGauge:      manaGauge,
+		allocGauge:     allocGauge,
+		registry:       registry,
 	}
 }
 
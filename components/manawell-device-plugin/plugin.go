@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	logg "github.com/fukaraca/runesmith/shared/log"
@@ -26,7 +27,10 @@ type DevicePlugin struct {
 	httpServer   *http.Server
 	healthServer *HealthServer
 	watcher      *PodWatcher
-	stopCh       chan bool
+	podResources podDeviceLister
+
+	mu     sync.Mutex // guards stopCh, a restart replaces it while the streams of the old run still watch it
+	stopCh chan bool
 }
 
 func NewManaDevicePlugin(config *Config, manager *ManaGer) *DevicePlugin {
@@ -34,7 +38,9 @@ func NewManaDevicePlugin(config *Config, manager *ManaGer) *DevicePlugin {
 		logger:  logg.New(config.Log),
 		config:  config,
 		manager: manager,
-		stopCh:  make(chan bool),
+		podResources: NewPodResourcesClient(config.Kubelet.PodResourcesSocket, config.Mana.ResourceName,
+			config.Server.Timeout),
+		stopCh: make(chan bool),
 	}
 }
 
@@ -42,6 +48,14 @@ func (p *DevicePlugin) Start(ctx context.Context) error {
 	if err := p.cleanup(); err != nil {
 		return fmt.Errorf("failed to cleanup previous socket: %w", err)
 	}
+	stopCh := make(chan bool) // closed by the Stop before a restart
+	p.mu.Lock()
+	p.stopCh = stopCh
+	p.mu.Unlock()
+	// before serving, the kubelet may call Allocate right after we register
+	if err := p.restoreAllocations(ctx); err != nil {
+		p.logger.Warn("could not restore allocations, all devices start free", slog.Any("error", err))
+	}
 
 	p.grpcServer = grpc.NewServer()
 	pluginapi.RegisterDevicePluginServer(p.grpcServer, p)
@@ -62,6 +76,7 @@ func (p *DevicePlugin) Start(ctx context.Context) error {
 	}
 	go p.watcher.Start(ctx)
 	go p.watchKubeletRestart(ctx)
+	go p.healthLoop(ctx, stopCh)
 
 	go p.startHTTPServer()
 
@@ -72,7 +87,14 @@ func (p *DevicePlugin) Start(ctx context.Context) error {
 func (p *DevicePlugin) Stop() {
 	log.Println("Stopping device plugin...")
 
-	close(p.stopCh)
+	p.mu.Lock()
+	select {
+	case <-p.stopCh:
+		// a failed restart stopped us already
+	default:
+		close(p.stopCh)
+	}
+	p.mu.Unlock()
 
 	if p.grpcServer != nil {
 		p.grpcServer.Stop()
@@ -104,7 +126,7 @@ func (p *DevicePlugin) serve(ctx context.Context) error {
 	go func() {
 		p.healthServer.Start()
 		if err := p.grpcServer.Serve(sock); err != nil {
-			p.logger.Error("gRPC server error", err) // TODO errgroup or healthchecker
+			p.logger.Error("gRPC server error", slog.Any("error", err)) // TODO errgroup or healthchecker
 			p.healthServer.Stop()
 		}
 	}()
@@ -169,7 +191,7 @@ func (p *DevicePlugin) watchKubeletRestart(ctx context.Context) {
 				p.logger.Warn("kubelet restarted, restarting everything")
 				p.Stop()
 				if err := p.Start(ctx); err != nil {
-					p.logger.Error("Failed to restart", err)
+					p.logger.Error("Failed to restart", slog.Any("error", err))
 					p.Stop()
 				} else {
 					return // we are running another watcher already
@@ -192,27 +214,35 @@ func (p *DevicePlugin) cleanup() error {
 func (p *DevicePlugin) GetDevicePluginOptions(ctx context.Context, empty *pluginapi.Empty) (*pluginapi.DevicePluginOptions, error) {
 	return &pluginapi.DevicePluginOptions{
 		PreStartRequired:                false,
-		GetPreferredAllocationAvailable: false,
+		GetPreferredAllocationAvailable: true,
 	}, nil
 }
 
-func (p *DevicePlugin) ListAndWatch(empty *pluginapi.Empty, stream pluginapi.DevicePlugin_ListAndWatchServer) error {
-	devices := p.manager.GetAllDevices()
-	if err := stream.Send(&pluginapi.ListAndWatchResponse{Devices: devices}); err != nil {
-		return err
-	}
+// stopChan is the stop channel of the current run
+func (p *DevicePlugin) stopChan() <-chan bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopCh
+}
 
-	ticker := time.NewTicker(time.Minute * 5) // there is no meaning of healthiness on our mana droplets anyway
+func (p *DevicePlugin) ListAndWatch(empty *pluginapi.Empty, stream pluginapi.DevicePlugin_ListAndWatchServer) error {
+	stopCh := p.stopChan() // the stream belongs to this run, a restart opens a new one
+	ticker := time.NewTicker(time.Minute * 5)
 	defer ticker.Stop()
 
 	for {
+		changed := p.manager.Changed()
+		if err := stream.Send(&pluginapi.ListAndWatchResponse{Devices: p.manager.GetAllDevices()}); err != nil {
+			return err
+		}
+
 		select {
-		case <-p.stopCh:
+		case <-stopCh:
 			return nil
+		case <-stream.Context().Done():
+			return nil
+		case <-changed: // overheated or resized, the kubelet gets to know right away
 		case <-ticker.C:
-			if err := stream.Send(&pluginapi.ListAndWatchResponse{Devices: devices}); err != nil {
-				return err
-			}
 			p.logger.Info("stats",
 				slog.Int("allocated", p.manager.GetAllocatedMana()), slog.Int("available", p.manager.GetAvailableMana()))
 		}
@@ -225,30 +255,42 @@ func (p *DevicePlugin) Allocate(ctx context.Context, req *pluginapi.AllocateRequ
 	}
 
 	for i, containerReq := range req.ContainerRequests {
+		// the kubelet picked the devices already, guided by GetPreferredAllocation, handing out others would
+		// make its accounting and ours disagree
 		count := len(containerReq.DevicesIDs)
-		allocatedIDs, err := p.manager.AllocateDevices(count)
-		if err != nil {
+		if err := p.manager.AllocateDevices(containerReq.DevicesIDs); err != nil {
 			return nil, fmt.Errorf("failed to allocate devices: %w", err)
 		}
 
 		containerResponse := &pluginapi.ContainerAllocateResponse{
 			Envs: map[string]string{
 				"MANA_ENERGY_TYPE":    p.config.Mana.EnergyType.String(),
-				"MANA_DEVICE_IDS":     strings.Join(allocatedIDs, ","),
+				"MANA_DEVICE_IDS":     strings.Join(containerReq.DevicesIDs, ","),
 				"MANA_COUNT":          fmt.Sprintf("%d", count),
 				"DAEMON_SERVICE_ADDR": fmt.Sprintf("http://%s:%s", p.config.Node.DaemonServiceName, p.config.Server.Port),
 			},
 		}
 
 		response.ContainerResponses[i] = containerResponse
-		p.logger.Info(fmt.Sprintf("allocated %d mana devices: %v", count, allocatedIDs))
+		p.logger.Info(fmt.Sprintf("allocated %d mana devices: %v", count, containerReq.DevicesIDs))
 	}
 
 	return response, nil
 }
 
 func (p *DevicePlugin) GetPreferredAllocation(ctx context.Context, req *pluginapi.PreferredAllocationRequest) (*pluginapi.PreferredAllocationResponse, error) {
-	return &pluginapi.PreferredAllocationResponse{}, nil
+	response := &pluginapi.PreferredAllocationResponse{
+		ContainerResponses: make([]*pluginapi.ContainerPreferredAllocationResponse, len(req.ContainerRequests)),
+	}
+
+	for i, containerReq := range req.ContainerRequests {
+		ids := p.manager.PreferredDevices(containerReq.AvailableDeviceIDs, containerReq.MustIncludeDeviceIDs,
+			int(containerReq.AllocationSize))
+		response.ContainerResponses[i] = &pluginapi.ContainerPreferredAllocationResponse{DeviceIDs: ids}
+		p.logger.Debug("preferred allocation", slog.Int("size", int(containerReq.AllocationSize)), slog.Any("devices", ids))
+	}
+
+	return response, nil
 }
 
 func (p *DevicePlugin) PreStartContainer(ctx context.Context, req *pluginapi.PreStartContainerRequest) (*pluginapi.PreStartContainerResponse, error) {
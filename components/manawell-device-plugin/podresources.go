@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+// podDevices is what the kubelet has handed to a pod out of our resource
+type podDevices struct {
+	PodUID    string // the PodResources API doesn't tell the UID, only the checkpoint does
+	PodName   string
+	Namespace string
+	DeviceIDs []string
+}
+
+// PodResourcesClient asks the kubelet which devices the pods of the node hold
+type PodResourcesClient struct {
+	socketPath   string
+	resourceName string
+	timeout      time.Duration
+}
+
+func NewPodResourcesClient(socketPath, resourceName string, timeout time.Duration) *PodResourcesClient {
+	return &PodResourcesClient{
+		socketPath:   socketPath,
+		resourceName: resourceName,
+		timeout:      timeout,
+	}
+}
+
+// List returns the pods holding our devices, pods without any are left out
+func (c *PodResourcesClient) List(ctx context.Context) ([]podDevices, error) {
+	conn, err := grpc.NewClient("unix://"+c.socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial pod resources socket: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := podresourcesapi.NewPodResourcesListerClient(conn).List(ctx, &podresourcesapi.ListPodResourcesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod resources: %w", err)
+	}
+
+	var pods []podDevices
+	for _, pod := range resp.GetPodResources() {
+		var ids []string
+		for _, container := range pod.GetContainers() {
+			for _, dev := range container.GetDevices() {
+				if dev.GetResourceName() == c.resourceName {
+					ids = append(ids, dev.GetDeviceIds()...)
+				}
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		pods = append(pods, podDevices{PodName: pod.GetName(), Namespace: pod.GetNamespace(), DeviceIDs: ids})
+	}
+	return pods, nil
+}
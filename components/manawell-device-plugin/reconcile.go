@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const podUIDIndex = "byUID"
+
+var (
+	orphanedReleased = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "manawell_orphaned_allocations_released_total",
+		Help: "Allocations released by the reconciler because their pod was gone and the event was missed",
+	})
+	orphanedMana = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "manawell_orphaned_mana_released_total",
+		Help: "Mana devices given back by the reconciler because no live pod held them",
+	})
+	unmappedAdopted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "manawell_unmapped_allocations_adopted_total",
+		Help: "Allocations the kubelet reported for running pods that weren't mapped to them",
+	})
+	staleAllocations = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "manawell_stale_allocations",
+		Help: "Allocations older than the stale age without a matching pod that the kubelet still holds",
+	})
+)
+
+// reconcileLoop diffs the allocations against the live pods every ReconcileInterval until the watcher stops
+func (pw *PodWatcher) reconcileLoop() {
+	if pw.watcher.ReconcileInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(pw.watcher.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pw.stopCh:
+			return
+		case <-ticker.C:
+			pw.reconcileAllocations(time.Now())
+		}
+	}
+}
+
+// reconcileAllocations catches what the pod events missed. An allocation whose pod finished is released, so
+// is one whose pod is gone from the cache while the kubelet no longer holds its devices. Devices the kubelet
+// still holds for a pod we don't know are never released, they are flagged once the allocation is older than
+// StaleAllocationAge. Devices the kubelet holds for a live pod without an allocation are mapped to it, devices
+// Allocate handed out that nobody maps or holds are freed after UnmappedGracePeriod.
+func (pw *PodWatcher) reconcileAllocations(now time.Time) {
+	// without the kubelet we can't tell gone pods from ones the cache hasn't seen yet, nothing is released then
+	held, err := pw.podResources.List(context.Background())
+	kubeletKnown := err == nil
+	if err != nil {
+		pw.logger.Warn("reconcile: pod resources unavailable", slog.Any("error", err))
+	}
+	heldIDs := make(map[string]bool)
+	for _, pod := range held {
+		for _, id := range pod.DeviceIDs {
+			heldIDs[id] = true
+		}
+	}
+
+	stale := make(map[string]bool)
+	for uid, allocation := range pw.manager.GetAllAllocations() {
+		pod, found := pw.podByUID(uid)
+		switch {
+		case found && (isTerminal(pod) || pod.DeletionTimestamp != nil):
+			pw.releaseOrphaned(uid, allocation.PodName, len(allocation.DeviceIDs), "pod finished")
+		case found:
+		case kubeletKnown && !holdsAny(heldIDs, allocation.DeviceIDs):
+			pw.releaseOrphaned(uid, allocation.PodName, len(allocation.DeviceIDs), "pod gone")
+		case now.Sub(time.Unix(allocation.Timestamp, 0)) >= pw.watcher.StaleAllocationAge:
+			stale[uid] = true
+			if !pw.stale[uid] {
+				pw.logger.Warn("reconcile: stale allocation without a pod", slog.String("uid", uid),
+					slog.String("name", allocation.PodName), slog.String("namespace", allocation.Namespace),
+					slog.Any("devices", allocation.DeviceIDs), slog.Time("since", time.Unix(allocation.Timestamp, 0)))
+			}
+		}
+	}
+	pw.stale = stale
+	staleAllocations.Set(float64(len(stale)))
+
+	for _, pod := range held {
+		live, ok := pw.podByName(pod.Namespace, pod.PodName)
+		if !ok || isGone(live) {
+			continue
+		}
+		if _, mapped := pw.manager.GetAllocation(string(live.UID)); mapped {
+			continue
+		}
+		pw.manager.MapAllocations(string(live.UID), live.Name, live.Namespace, pod.DeviceIDs, now.Unix())
+		unmappedAdopted.Inc()
+		pw.logger.Warn("reconcile: adopted unmapped allocation", slog.String("name", live.Name),
+			slog.String("uid", string(live.UID)), slog.Any("devices", pod.DeviceIDs))
+	}
+
+	if !kubeletKnown {
+		return
+	}
+	if released := pw.manager.ReleaseUnmapped(heldIDs, now, pw.watcher.UnmappedGracePeriod); len(released) > 0 {
+		orphanedMana.Add(float64(len(released)))
+		pw.logger.Warn("reconcile: released unmapped devices", slog.Any("devices", released),
+			slog.Int("allocated", pw.manager.GetAllocatedMana()), slog.Int("available", pw.manager.GetAvailableMana()))
+	}
+}
+
+func (pw *PodWatcher) releaseOrphaned(uid, name string, devices int, reason string) {
+	if err := pw.manager.ReleaseDevices(uid); err != nil {
+		return // released by an event in the meantime
+	}
+	orphanedReleased.Inc()
+	orphanedMana.Add(float64(devices))
+	pw.logger.Warn("reconcile: released orphaned allocation", slog.String("uid", uid), slog.String("name", name),
+		slog.String("reason", reason), slog.Int("devices", devices),
+		slog.Int("allocated", pw.manager.GetAllocatedMana()), slog.Int("available", pw.manager.GetAvailableMana()))
+}
+
+func (pw *PodWatcher) podByUID(uid string) (*v1.Pod, bool) {
+	objs, err := pw.podInf.Informer().GetIndexer().ByIndex(podUIDIndex, uid)
+	if err != nil || len(objs) == 0 {
+		return nil, false
+	}
+	pod, ok := objs[0].(*v1.Pod)
+	return pod, ok
+}
+
+func (pw *PodWatcher) podByName(namespace, name string) (*v1.Pod, bool) {
+	obj, exists, err := pw.podInf.Informer().GetIndexer().GetByKey(types.NamespacedName{Namespace: namespace, Name: name}.String())
+	if err != nil || !exists {
+		return nil, false
+	}
+	pod, ok := obj.(*v1.Pod)
+	return pod, ok
+}
+
+func holdsAny(held map[string]bool, ids []string) bool {
+	for _, id := range ids {
+		if held[id] {
+			return true
+		}
+	}
+	return false
+}
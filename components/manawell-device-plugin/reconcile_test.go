@@ -0,0 +1,149 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newReconcileWatcher has a pod cache that is filled by hand instead of an informer run
+func newReconcileWatcher(t *testing.T, pods ...*v1.Pod) (*PodWatcher, *fakePodResources) {
+	t.Helper()
+	pw, fakeKubelet := newTestWatcher(t, 4)
+	pw.watcher = WatcherConfig{StaleAllocationAge: 10 * time.Minute}
+	pw.stale = make(map[string]bool)
+	pw.podInf = informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Pods()
+	if err := pw.podInf.Informer().AddIndexers(cache.Indexers{
+		podUIDIndex: func(obj interface{}) ([]string, error) { return []string{string(obj.(*v1.Pod).UID)}, nil },
+	}); err != nil {
+		t.Fatalf("add indexer: %v", err)
+	}
+	for _, pod := range pods {
+		if err := pw.podInf.Informer().GetIndexer().Add(pod); err != nil {
+			t.Fatalf("add pod: %v", err)
+		}
+	}
+	return pw, fakeKubelet
+}
+
+func TestReconcileReleasesOrphans(t *testing.T) {
+	finished := enchanterPod("finished", "uid-finished", v1.PodSucceeded)
+	pw, fakeKubelet := newReconcileWatcher(t, finished)
+	now := time.Now()
+	pw.manager.MapAllocations("uid-finished", "finished", "default", []string{"fire-001"}, now.Unix())
+	pw.manager.MapAllocations("uid-gone", "gone", "default", []string{"fire-002"}, now.Unix())
+	// the kubelet still holds fire-003 for a pod the cache doesn't know, it isn't ours to free
+	pw.manager.MapAllocations("uid-unknown", "unknown", "default", []string{"fire-003"}, now.Unix())
+	fakeKubelet.hold("default", "unknown", fireResource, "fire-003")
+
+	pw.reconcileAllocations(now)
+	if got := len(pw.manager.GetAllAllocations()); got != 1 {
+		t.Fatalf("allocations = %d, want only the one the kubelet holds", got)
+	}
+	if _, ok := pw.manager.GetAllocation("uid-unknown"); !ok {
+		t.Error("allocation the kubelet holds was released")
+	}
+	if got := pw.manager.GetAvailableMana(); got != 3 {
+		t.Errorf("available mana = %d, want 3", got)
+	}
+}
+
+func TestReconcileKeepsAllocationsWithoutKubelet(t *testing.T) {
+	pw, _ := newReconcileWatcher(t)
+	pw.podResources = NewPodResourcesClient(t.TempDir()+"/missing.sock", fireResource, time.Second)
+	now := time.Now()
+	pw.manager.MapAllocations("uid-gone", "gone", "default", []string{"fire-001"}, now.Add(-time.Hour).Unix())
+
+	pw.reconcileAllocations(now)
+	if _, ok := pw.manager.GetAllocation("uid-gone"); !ok {
+		t.Fatal("allocation released without asking the kubelet")
+	}
+	if !pw.stale["uid-gone"] {
+		t.Error("old allocation without a pod was not flagged")
+	}
+}
+
+func TestReconcileFlagsStaleAllocations(t *testing.T) {
+	pw, fakeKubelet := newReconcileWatcher(t)
+	now := time.Now()
+	pw.manager.MapAllocations("uid-fresh", "fresh", "default", []string{"fire-001"}, now.Unix())
+	pw.manager.MapAllocations("uid-old", "old", "default", []string{"fire-002"}, now.Add(-time.Hour).Unix())
+	fakeKubelet.hold("default", "fresh", fireResource, "fire-001")
+	fakeKubelet.hold("default", "old", fireResource, "fire-002")
+
+	pw.reconcileAllocations(now)
+	if pw.stale["uid-fresh"] || !pw.stale["uid-old"] {
+		t.Errorf("stale = %v, want only uid-old", pw.stale)
+	}
+	if got := len(pw.manager.GetAllAllocations()); got != 2 {
+		t.Errorf("allocations = %d, stale ones must be kept", got)
+	}
+
+	// the pod shows up, it isn't stale anymore
+	if err := pw.podInf.Informer().GetIndexer().Add(enchanterPod("old", "uid-old", v1.PodRunning)); err != nil {
+		t.Fatalf("add pod: %v", err)
+	}
+	pw.reconcileAllocations(now)
+	if pw.stale["uid-old"] {
+		t.Error("allocation with a pod is still flagged")
+	}
+}
+
+func TestReconcileAdoptsUnmappedAllocations(t *testing.T) {
+	running := enchanterPod("blade", "uid-blade", v1.PodRunning)
+	pending := enchanterPod("staff", "uid-staff", v1.PodPending)
+	failed := enchanterPod("wand", "uid-wand", v1.PodFailed)
+	pw, fakeKubelet := newReconcileWatcher(t, running, pending, failed)
+	fakeKubelet.hold("default", "blade", fireResource, "fire-001", "fire-002")
+	fakeKubelet.hold("default", "staff", fireResource, "fire-003")
+	fakeKubelet.hold("default", "wand", fireResource, "fire-004")
+
+	pw.reconcileAllocations(time.Now())
+	allocation, ok := pw.manager.GetAllocation("uid-blade")
+	if !ok || len(allocation.DeviceIDs) != 2 {
+		t.Fatalf("running pod wasn't adopted: %+v", allocation)
+	}
+	if _, ok = pw.manager.GetAllocation("uid-staff"); !ok {
+		t.Error("pending pod wasn't adopted")
+	}
+	if _, ok = pw.manager.GetAllocation("uid-wand"); ok {
+		t.Error("failed pod was adopted")
+	}
+	if got := pw.manager.GetAvailableMana(); got != 1 {
+		t.Errorf("available mana = %d, want 1", got)
+	}
+}
+
+func TestReconcileReleasesUnmappedDevices(t *testing.T) {
+	pw, fakeKubelet := newReconcileWatcher(t, enchanterPod("blade", "uid-blade", v1.PodRunning))
+	pw.watcher.UnmappedGracePeriod = time.Minute
+	// Allocate handed out three devices, the kubelet only reports fire-003 for a pod
+	if err := pw.manager.AllocateDevices([]string{"fire-001", "fire-002", "fire-003"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	pw.manager.MapAllocations("uid-blade", "blade", "default", []string{"fire-001"}, time.Now().Unix())
+	fakeKubelet.hold("default", "staff", fireResource, "fire-003")
+
+	pw.reconcileAllocations(time.Now())
+	if got := pw.manager.GetAvailableMana(); got != 1 {
+		t.Fatalf("freed within the grace period, available mana = %d, want 1", got)
+	}
+
+	pw.reconcileAllocations(time.Now().Add(2 * time.Minute))
+	if got := pw.manager.GetAvailableMana(); got != 2 {
+		t.Errorf("available mana = %d, want 2", got)
+	}
+	if _, ok := pw.manager.GetAllocation("uid-blade"); !ok {
+		t.Error("mapped device was released")
+	}
+	if err := pw.manager.AllocateDevices([]string{"fire-002"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	if got := pw.manager.GetAvailableMana(); got != 1 {
+		t.Errorf("released device wasn't free, available mana = %d, want 1", got)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/fukaraca/runesmith/shared"
@@ -31,6 +33,9 @@ func (p *DevicePlugin) startHTTPServer() {
 	p.httpServer = srv
 }
 
+// handleAllocation takes the self-report of an enchanter as a hint only, the devices are the ones the kubelet
+// says the pod holds. A report the kubelet doesn't back is dropped, remapping with it would free devices that
+// are held. The enchanter gives up on an error, so dropping still answers Accepted.
 func (p *DevicePlugin) handleAllocation(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -42,8 +47,27 @@ func (p *DevicePlugin) handleAllocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	p.manager.MapAllocations(ai.PodUID, ai.PodName, ai.Namespace, ai.DeviceIDs, ai.Timestamp)
-	w.WriteHeader(http.StatusCreated)
+	pods, err := p.podResources.List(r.Context())
+	if err != nil {
+		p.logger.Warn("self-report dropped, pod resources unavailable", slog.String("name", ai.PodName), slog.Any("error", err))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	for _, held := range pods {
+		if held.Namespace != ai.Namespace || held.PodName != ai.PodName {
+			continue
+		}
+		if !slices.Equal(slices.Sorted(slices.Values(held.DeviceIDs)), slices.Sorted(slices.Values(ai.DeviceIDs))) {
+			p.logger.Warn("self-report disagrees with the kubelet", slog.String("name", ai.PodName),
+				slog.Any("reported", ai.DeviceIDs), slog.Any("held", held.DeviceIDs))
+		}
+		p.manager.MapAllocations(ai.PodUID, ai.PodName, ai.Namespace, held.DeviceIDs, ai.Timestamp)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	p.logger.Warn("self-report dropped, the kubelet holds no devices for the pod", slog.String("name", ai.PodName),
+		slog.Any("reported", ai.DeviceIDs))
+	w.WriteHeader(http.StatusAccepted)
 }
 
 func (p *DevicePlugin) handleHealthz(w http.ResponseWriter, r *http.Request) {
@@ -65,12 +89,12 @@ func (p *DevicePlugin) handleStatus(w http.ResponseWriter, r *http.Request) {
 		Name:        p.config.Mana.ResourceName,
 		Available:   p.manager.GetAvailableMana(),
 		Allocated:   p.manager.GetAllocatedMana(),
-		RunningJobs: len(p.manager.allocations),
+		RunningJobs: p.manager.GetAllocationCount(),
 	}
 	if _, err := os.Stat(p.config.Kubelet.SocketPath); err == nil {
 		status.Healthy = true
 	} else {
-		p.logger.Warn("kubelet socket check failed", err)
+		p.logger.Warn("kubelet socket check failed", slog.Any("error", err))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
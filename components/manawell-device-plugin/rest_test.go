@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+)
+
+func postAllocation(t *testing.T, p *DevicePlugin, ai shared.AllocationInfo) int {
+	t.Helper()
+	body, err := json.Marshal(ai)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	p.handleAllocation(rec, httptest.NewRequest(http.MethodPost, "/v1/allocations", strings.NewReader(string(body))))
+	return rec.Code
+}
+
+func TestHandleAllocationTrustsTheKubelet(t *testing.T) {
+	fake, socket := startFakePodResources(t)
+	p := &DevicePlugin{
+		manager:      NewManaGer(ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy}),
+		podResources: NewPodResourcesClient(socket, fireResource, time.Second),
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	if err := p.manager.AllocateDevices([]string{"fire-001", "fire-002"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	fake.hold("default", "blade", fireResource, "fire-001", "fire-002")
+	p.manager.MapAllocations("uid-blade", "blade", "default", []string{"fire-001", "fire-002"}, 1)
+
+	// a partial report must not free fire-002
+	code := postAllocation(t, p, shared.AllocationInfo{PodUID: "uid-blade", PodName: "blade", Namespace: "default",
+		DeviceIDs: []string{"fire-001"}})
+	if code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", code, http.StatusCreated)
+	}
+	allocation, _ := p.manager.GetAllocation("uid-blade")
+	if !slices.Equal(allocation.DeviceIDs, []string{"fire-001", "fire-002"}) {
+		t.Errorf("devices = %v, want the ones the kubelet reports", allocation.DeviceIDs)
+	}
+	if got := p.manager.GetAvailableMana(); got != 2 {
+		t.Errorf("available mana = %d, want 2", got)
+	}
+
+	code = postAllocation(t, p, shared.AllocationInfo{PodUID: "uid-staff", PodName: "staff", Namespace: "default",
+		DeviceIDs: []string{"fire-003"}})
+	if code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", code, http.StatusAccepted)
+	}
+	if _, ok := p.manager.GetAllocation("uid-staff"); ok {
+		t.Error("report the kubelet doesn't back was mapped")
+	}
+	if got := p.manager.GetAllocationCount(); got != 1 {
+		t.Errorf("allocations = %d, want 1", got)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// kubeletCheckpoint is the part of the device manager checkpoint we read. The kubelet owns the format,
+// so it is only the fallback when the PodResources API can't be reached.
+type kubeletCheckpoint struct {
+	Data struct {
+		PodDeviceEntries []struct {
+			PodUID       string
+			ResourceName string
+			// DeviceIDs is grouped by NUMA node since 1.20, a plain list before
+			DeviceIDs json.RawMessage
+		}
+	}
+}
+
+// readCheckpoint returns the pods the kubelet checkpointed our devices for
+func readCheckpoint(path, resourceName string) ([]podDevices, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubelet checkpoint: %w", err)
+	}
+	var cp kubeletCheckpoint
+	if err = json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet checkpoint: %w", err)
+	}
+
+	byUID := make(map[string]*podDevices)
+	var pods []*podDevices
+	for _, entry := range cp.Data.PodDeviceEntries {
+		if entry.ResourceName != resourceName {
+			continue
+		}
+		var ids []string
+		var perNUMA map[string][]string
+		if err = json.Unmarshal(entry.DeviceIDs, &perNUMA); err == nil {
+			for _, numaIDs := range perNUMA {
+				ids = append(ids, numaIDs...)
+			}
+		} else if err = json.Unmarshal(entry.DeviceIDs, &ids); err != nil {
+			return nil, fmt.Errorf("failed to parse device ids of pod %s: %w", entry.PodUID, err)
+		}
+		pod, ok := byUID[entry.PodUID]
+		if !ok { // one entry per container
+			pod = &podDevices{PodUID: entry.PodUID}
+			byUID[entry.PodUID] = pod
+			pods = append(pods, pod)
+		}
+		pod.DeviceIDs = append(pod.DeviceIDs, ids...)
+	}
+
+	result := make([]podDevices, 0, len(pods))
+	for _, pod := range pods {
+		result = append(result, *pod)
+	}
+	return result, nil
+}
+
+// restoreAllocations rebuilds the allocations the kubelet handed out before the plugin (re)started, so that
+// devices held by running pods aren't allocated twice. The PodResources API is asked first, the kubelet
+// checkpoint is the fallback.
+func (p *DevicePlugin) restoreAllocations(ctx context.Context) error {
+	live, liveErr := p.nodePods(ctx)
+	pods, err := p.podResources.List(ctx)
+	if err == nil && liveErr != nil {
+		err = liveErr // the pod names can't be resolved to UIDs then
+	}
+	if err != nil {
+		p.logger.Warn("pod resources unavailable, falling back to kubelet checkpoint", slog.Any("error", err))
+		if pods, err = readCheckpoint(p.config.Kubelet.CheckpointPath, p.config.Mana.ResourceName); err != nil {
+			return err
+		}
+	}
+	if liveErr != nil {
+		// better taken too long than handed out twice, the reconciler frees what nobody holds
+		p.logger.Warn("can't tell live pods, restoring every checkpointed allocation", slog.Any("error", liveErr))
+	} else {
+		pods = keepLive(pods, live)
+	}
+
+	now := time.Now().Unix()
+	allocations := make([]shared.AllocationInfo, 0, len(pods))
+	for _, pod := range pods {
+		allocations = append(allocations, shared.AllocationInfo{
+			PodUID:    pod.PodUID,
+			PodName:   pod.PodName,
+			Namespace: pod.Namespace,
+			DeviceIDs: pod.DeviceIDs,
+			Timestamp: now,
+		})
+	}
+	unknown := p.manager.RestoreAllocations(allocations)
+	if unknown > 0 {
+		p.logger.Warn("kubelet holds devices we no longer serve", slog.Int("devices", unknown))
+	}
+	p.logger.Info("restored allocations", slog.Int("pods", len(allocations)),
+		slog.Int("allocated", p.manager.GetAllocatedMana()), slog.Int("available", p.manager.GetAvailableMana()))
+	return nil
+}
+
+// nodePods lists the pods of the node from the API server
+func (p *DevicePlugin) nodePods(ctx context.Context) ([]v1.Pod, error) {
+	clients, err := getKubernetesClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	list, err := clients.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + p.config.Node.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of the node: %w", err)
+	}
+	return list.Items, nil
+}
+
+// keepLive leaves out the pods that are gone or ended, their devices are free again. The rest is completed
+// from the live pods, PodResources doesn't tell the UID and the checkpoint tells nothing but the UID.
+func keepLive(pods []podDevices, live []v1.Pod) []podDevices {
+	byUID := make(map[string]*v1.Pod, len(live))
+	byName := make(map[types.NamespacedName]*v1.Pod, len(live))
+	for i := range live {
+		if isGone(&live[i]) {
+			continue
+		}
+		byUID[string(live[i].UID)] = &live[i]
+		byName[types.NamespacedName{Namespace: live[i].Namespace, Name: live[i].Name}] = &live[i]
+	}
+
+	kept := make([]podDevices, 0, len(pods))
+	for _, pod := range pods {
+		var found *v1.Pod
+		if pod.PodUID != "" {
+			found = byUID[pod.PodUID]
+		} else {
+			found = byName[types.NamespacedName{Namespace: pod.Namespace, Name: pod.PodName}]
+		}
+		if found == nil {
+			continue
+		}
+		pod.PodUID, pod.PodName, pod.Namespace = string(found.UID), found.Name, found.Namespace
+		kept = append(kept, pod)
+	}
+	return kept
+}
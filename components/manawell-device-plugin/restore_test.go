@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadCheckpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint string
+		want       map[string][]string
+	}{
+		{
+			name: "devices per NUMA node",
+			checkpoint: `{"Data":{"PodDeviceEntries":[
+				{"PodUID":"uid-blade","ContainerName":"enchanter","ResourceName":"manawell.io/fire","DeviceIDs":{"0":["fire-001"],"1":["fire-002"]}},
+				{"PodUID":"uid-staff","ContainerName":"enchanter","ResourceName":"manawell.io/frost","DeviceIDs":{"0":["frost-001"]}}
+			]},"Checksum":1}`,
+			want: map[string][]string{"uid-blade": {"fire-001", "fire-002"}},
+		},
+		{
+			name: "plain list before 1.20",
+			checkpoint: `{"Data":{"PodDeviceEntries":[
+				{"PodUID":"uid-blade","ContainerName":"enchanter","ResourceName":"manawell.io/fire","DeviceIDs":["fire-001","fire-002"]},
+				{"PodUID":"uid-staff","ContainerName":"enchanter","ResourceName":"manawell.io/frost","DeviceIDs":["frost-001"]}
+			]},"Checksum":1}`,
+			want: map[string][]string{"uid-blade": {"fire-001", "fire-002"}},
+		},
+		{
+			name: "one entry per container",
+			checkpoint: `{"Data":{"PodDeviceEntries":[
+				{"PodUID":"uid-blade","ContainerName":"enchanter","ResourceName":"manawell.io/fire","DeviceIDs":{"0":["fire-001"]}},
+				{"PodUID":"uid-blade","ContainerName":"sidecar","ResourceName":"manawell.io/fire","DeviceIDs":{"0":["fire-003"]}},
+				{"PodUID":"uid-staff","ContainerName":"enchanter","ResourceName":"manawell.io/fire","DeviceIDs":{"0":["fire-002"]}}
+			]},"Checksum":1}`,
+			want: map[string][]string{"uid-blade": {"fire-001", "fire-003"}, "uid-staff": {"fire-002"}},
+		},
+		{
+			name:       "no entries",
+			checkpoint: `{"Data":{"PodDeviceEntries":null,"RegisteredDevices":{}},"Checksum":1}`,
+			want:       map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kubelet_internal_checkpoint")
+			if err := os.WriteFile(path, []byte(tt.checkpoint), 0o600); err != nil {
+				t.Fatalf("write checkpoint: %v", err)
+			}
+			pods, err := readCheckpoint(path, fireResource)
+			if err != nil {
+				t.Fatalf("readCheckpoint: %v", err)
+			}
+			got := make(map[string][]string, len(pods))
+			for _, pod := range pods {
+				ids := slices.Clone(pod.DeviceIDs)
+				slices.Sort(ids)
+				got[pod.PodUID] = ids
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("pods = %v, want %v", got, tt.want)
+			}
+			for uid, ids := range tt.want {
+				if !slices.Equal(got[uid], ids) {
+					t.Errorf("devices of %s = %v, want %v", uid, got[uid], ids)
+				}
+			}
+		})
+	}
+}
+
+func TestReadCheckpointRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubelet_internal_checkpoint")
+	if _, err := readCheckpoint(path, fireResource); err == nil {
+		t.Error("missing checkpoint was read")
+	}
+	checkpoint := `{"Data":{"PodDeviceEntries":[{"PodUID":"uid-blade","ResourceName":"manawell.io/fire","DeviceIDs":"fire-001"}]}}`
+	if err := os.WriteFile(path, []byte(checkpoint), 0o600); err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+	if _, err := readCheckpoint(path, fireResource); err == nil {
+		t.Error("device ids that are neither a list nor a map were read")
+	}
+}
+
+func TestKeepLive(t *testing.T) {
+	deleting := enchanterPod("deleting", "uid-deleting", v1.PodRunning)
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	live := []v1.Pod{
+		*enchanterPod("blade", "uid-blade", v1.PodRunning),
+		*enchanterPod("staff", "uid-staff", v1.PodPending),
+		*enchanterPod("done", "uid-done", v1.PodSucceeded),
+		*deleting,
+	}
+
+	tests := []struct {
+		name string
+		pods []podDevices
+		want []podDevices
+	}{
+		{
+			name: "checkpoint entries are completed by UID",
+			pods: []podDevices{
+				{PodUID: "uid-blade", DeviceIDs: []string{"fire-001"}},
+				{PodUID: "uid-staff", DeviceIDs: []string{"fire-002"}},
+			},
+			want: []podDevices{
+				{PodUID: "uid-blade", PodName: "blade", Namespace: "default", DeviceIDs: []string{"fire-001"}},
+				{PodUID: "uid-staff", PodName: "staff", Namespace: "default", DeviceIDs: []string{"fire-002"}},
+			},
+		},
+		{
+			name: "pod resources entries are completed by name",
+			pods: []podDevices{{PodName: "blade", Namespace: "default", DeviceIDs: []string{"fire-001"}}},
+			want: []podDevices{{PodUID: "uid-blade", PodName: "blade", Namespace: "default", DeviceIDs: []string{"fire-001"}}},
+		},
+		{
+			name: "dead pods are left out",
+			pods: []podDevices{
+				{PodUID: "uid-gone", DeviceIDs: []string{"fire-001"}},
+				{PodUID: "uid-done", DeviceIDs: []string{"fire-002"}},
+				{PodUID: "uid-deleting", DeviceIDs: []string{"fire-003"}},
+				{PodName: "blade", Namespace: "elsewhere", DeviceIDs: []string{"fire-004"}},
+			},
+			want: []podDevices{},
+		},
+		{
+			name: "a recreated pod of the same name isn't the checkpointed one",
+			pods: []podDevices{{PodUID: "uid-old-blade", PodName: "blade", Namespace: "default", DeviceIDs: []string{"fire-001"}}},
+			want: []podDevices{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keepLive(tt.pods, live)
+			if len(got) != len(tt.want) {
+				t.Fatalf("kept = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].PodUID != tt.want[i].PodUID || got[i].PodName != tt.want[i].PodName ||
+					got[i].Namespace != tt.want[i].Namespace || !slices.Equal(got[i].DeviceIDs, tt.want[i].DeviceIDs) {
+					t.Errorf("kept[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreAllocations(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 3, EnergyType: shared.FireEnergy})
+	unknown := m.RestoreAllocations([]shared.AllocationInfo{
+		{PodUID: "uid-blade", DeviceIDs: []string{"fire-001", "fire-009"}},
+		{PodUID: "uid-empty", DeviceIDs: []string{"fire-042"}},
+	})
+	if unknown != 2 {
+		t.Errorf("unknown devices = %d, want 2", unknown)
+	}
+	if got := m.GetAvailableMana(); got != 2 {
+		t.Errorf("available mana = %d, want 2", got)
+	}
+	if _, ok := m.GetAllocation("uid-empty"); ok {
+		t.Error("allocation without a device we serve was restored")
+	}
+	if err := m.AllocateDevices([]string{"fire-002", "fire-003"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	if got := m.GetAvailableMana(); got != 0 {
+		t.Errorf("restored device is still free, available mana = %d", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,19 +16,27 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
+// podDeviceLister tells which devices the pods of the node hold
+type podDeviceLister interface {
+	List(ctx context.Context) ([]podDevices, error)
+}
+
 type PodWatcher struct {
-	watcher     WatcherConfig
-	node        NodeConfig
-	manager     *ManaGer
-	kubeClients kubernetes.Interface
-	informer    cache.SharedIndexInformer
-	stopCh      chan struct{}
-	logger      *slog.Logger
+	watcher      WatcherConfig
+	node         NodeConfig
+	manager      *ManaGer
+	kubeClients  kubernetes.Interface
+	podResources podDeviceLister
+	informer     cache.SharedIndexInformer
+	stopCh       chan struct{}
+	logger       *slog.Logger
 
 	podSelector labels.Selector
 	resourceKey string
 
 	podInf v1Informer.PodInformer
+	// stale are the allocations already flagged by the reconciler, by pod UID
+	stale map[string]bool
 }
 
 func NewPodWatcher(cfg *Config, manager *ManaGer, logger *slog.Logger) (*PodWatcher, error) {
@@ -46,10 +55,13 @@ func NewPodWatcher(cfg *Config, manager *ManaGer, logger *slog.Logger) (*PodWatc
 		node:        cfg.Node,
 		manager:     manager,
 		kubeClients: clients,
+		podResources: NewPodResourcesClient(cfg.Kubelet.PodResourcesSocket, cfg.Mana.ResourceName,
+			cfg.Server.Timeout),
 		logger:      logger,
 		podSelector: sel,
 		resourceKey: fmt.Sprintf("manawell.io/%s", manager.energyType),
 		stopCh:      make(chan struct{}),
+		stale:       make(map[string]bool),
 	}, nil
 }
 
@@ -60,14 +72,13 @@ func (pw *PodWatcher) Start(ctx context.Context) error {
 			lo.LabelSelector = pw.podSelector.String()
 		}),
 	}
-	options = append(options, informers.WithNamespace(pw.node.Namespace))
+	// enchantments run in any namespace, the reconciler takes a pod missing from the cache for gone
 	fac := informers.NewSharedInformerFactoryWithOptions(
 		pw.kubeClients, pw.watcher.ResyncInterval, options...,
 	)
 	pw.podInf = fac.Core().V1().Pods()
-	/* // TODO maybe we reconcile the mana by allocated pod ids
 	err := pw.podInf.Informer().AddIndexers(cache.Indexers{
-		"byUID": func(obj interface{}) ([]string, error) {
+		podUIDIndex: func(obj interface{}) ([]string, error) {
 			if pod, ok := obj.(*v1.Pod); ok {
 				return []string{string(pod.UID)}, nil
 			}
@@ -77,9 +88,8 @@ func (pw *PodWatcher) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("add indexer: %w", err)
 	}
-	*/
 
-	_, err := pw.podInf.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	_, err = pw.podInf.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    pw.onPodAdd,
 		UpdateFunc: pw.onPodUpdate,
 		DeleteFunc: pw.onPodDelete,
@@ -102,6 +112,7 @@ func (pw *PodWatcher) Start(ctx context.Context) error {
 		<-ctx.Done()
 		pw.Stop()
 	}()
+	go pw.reconcileLoop()
 
 	<-pw.stopCh
 	return nil
@@ -122,8 +133,10 @@ func (pw *PodWatcher) onPodAdd(obj any) {
 	if !ok {
 		return
 	}
-	// just for action
 	pw.logger.Info("pod add: pod detected", slog.String("name", pod.Name))
+	if !isGone(pod) { // may hold devices since before we started watching
+		pw.mapPodDevices(pod)
+	}
 }
 
 func (pw *PodWatcher) onPodUpdate(oldObj, newObj any) {
@@ -132,10 +145,24 @@ func (pw *PodWatcher) onPodUpdate(oldObj, newObj any) {
 		return
 	}
 
-	if isTerminal(newPod) || newPod.DeletionTimestamp != nil {
+	oldPod, ok := oldObj.(*v1.Pod)
+	if isGone(newPod) {
 		pw.logger.Info("pod update: pod detected", slog.String("name", newPod.Name), slog.Any("status", newPod.Status.Phase))
+		if !ok || !isGone(oldPod) {
+			pw.mapUnmapped(newPod) // it may have ended before we saw it holding devices
+		}
 		pw.releasePodResources(newPod)
+		return
+	}
+	if ok && oldPod.Status.Phase == newPod.Status.Phase {
+		return // status updates within a phase don't hand out devices we'd see any earlier
+	}
+	if newPod.Status.Phase == v1.PodRunning {
+		pw.mapPodDevices(newPod)
+		return
 	}
+	// the kubelet hands out the devices before the pod runs, a pending pod may end without ever running
+	pw.mapUnmapped(newPod)
 }
 
 func (pw *PodWatcher) onPodDelete(obj any) {
@@ -153,6 +180,9 @@ func (pw *PodWatcher) onPodDelete(obj any) {
 		}
 	}
 
+	if !isGone(pod) { // deleted without us seeing it end
+		pw.mapUnmapped(pod)
+	}
 	pw.releasePodResources(pod) // it won't affect if it was already released onPodUpdate()
 	pw.logger.Info("pod delete: pod detected", slog.String("name", pod.Name))
 }
@@ -161,6 +191,56 @@ func isTerminal(pod *v1.Pod) bool {
 	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
 }
 
+// isGone reports a pod that won't use its devices anymore
+func isGone(pod *v1.Pod) bool {
+	return isTerminal(pod) || pod.DeletionTimestamp != nil
+}
+
+// mapUnmapped maps the devices of a pod we don't know any of yet
+func (pw *PodWatcher) mapUnmapped(pod *v1.Pod) {
+	if _, ok := pw.manager.GetAllocation(string(pod.UID)); !ok {
+		pw.mapPodDevices(pod)
+	}
+}
+
+// requestsMana reports whether a container of the pod asks for our resource, only those hold our devices
+func (pw *PodWatcher) requestsMana(pod *v1.Pod) bool {
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			if _, ok := c.Resources.Limits[v1.ResourceName(pw.resourceKey)]; ok {
+				return true
+			}
+			if _, ok := c.Resources.Requests[v1.ResourceName(pw.resourceKey)]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mapPodDevices asks the kubelet which of our devices the pod holds and maps them to it, the pod doesn't
+// have to report itself
+func (pw *PodWatcher) mapPodDevices(pod *v1.Pod) {
+	if !pw.requestsMana(pod) {
+		return
+	}
+	pods, err := pw.podResources.List(context.Background())
+	if err != nil {
+		pw.logger.Warn("pod resources lookup failed", slog.String("name", pod.Name), slog.Any("error", err))
+		return
+	}
+	for _, held := range pods {
+		if held.Namespace != pod.Namespace || held.PodName != pod.Name {
+			continue
+		}
+		pw.manager.MapAllocations(string(pod.UID), pod.Name, pod.Namespace, held.DeviceIDs, time.Now().Unix())
+		pw.logger.Info("mapped mana", slog.String("name", pod.Name), slog.String("uid", string(pod.UID)),
+			slog.Any("devices", held.DeviceIDs))
+		return
+	}
+	pw.logger.Debug("pod holds no mana", slog.String("name", pod.Name))
+}
+
 func (pw *PodWatcher) releasePodResources(pod *v1.Pod) {
 	podID := string(pod.UID)
 	if err := pw.manager.ReleaseDevices(podID); err != nil {
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+const fireResource = "manawell.io/fire"
+
+// fakePodResources serves the PodResources List of a kubelet on a unix socket
+type fakePodResources struct {
+	podresourcesapi.UnimplementedPodResourcesListerServer
+	pods  []*podresourcesapi.PodResources
+	lists atomic.Int32 // List calls so far
+}
+
+func (f *fakePodResources) List(context.Context, *podresourcesapi.ListPodResourcesRequest) (*podresourcesapi.ListPodResourcesResponse, error) {
+	f.lists.Add(1)
+	return &podresourcesapi.ListPodResourcesResponse{PodResources: f.pods}, nil
+}
+
+// hold makes the pod hold the devices of the resource in a container of its own
+func (f *fakePodResources) hold(namespace, name, resource string, ids ...string) {
+	f.pods = append(f.pods, &podresourcesapi.PodResources{
+		Name:      name,
+		Namespace: namespace,
+		Containers: []*podresourcesapi.ContainerResources{{
+			Name:    "enchanter",
+			Devices: []*podresourcesapi.ContainerDevices{{ResourceName: resource, DeviceIds: ids}},
+		}},
+	})
+}
+
+func startFakePodResources(t *testing.T) (*fakePodResources, string) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "kubelet.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on %s: %v", socket, err)
+	}
+	fake := &fakePodResources{}
+	srv := grpc.NewServer()
+	podresourcesapi.RegisterPodResourcesListerServer(srv, fake)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return fake, socket
+}
+
+func newTestWatcher(t *testing.T, maxMana int) (*PodWatcher, *fakePodResources) {
+	t.Helper()
+	fake, socket := startFakePodResources(t)
+	return &PodWatcher{
+		manager:      NewManaGer(ManaConfig{MaxMana: maxMana, EnergyType: shared.FireEnergy}),
+		podResources: NewPodResourcesClient(socket, fireResource, time.Second),
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		resourceKey:  fireResource,
+	}, fake
+}
+
+func enchanterPod(name string, uid types.UID, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name: "enchanter",
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{fireResource: resource.MustParse("1")},
+			},
+		}}},
+		Status: v1.PodStatus{Phase: phase},
+	}
+}
+
+func TestPodResourcesClientList(t *testing.T) {
+	fake, socket := startFakePodResources(t)
+	fake.hold("default", "blade", fireResource, "fire-001", "fire-002")
+	fake.hold("default", "staff", "manawell.io/frost", "frost-001")
+	fake.pods[0].Containers = append(fake.pods[0].Containers, &podresourcesapi.ContainerResources{
+		Name:    "sidecar",
+		Devices: []*podresourcesapi.ContainerDevices{{ResourceName: fireResource, DeviceIds: []string{"fire-003"}}},
+	})
+
+	pods, err := NewPodResourcesClient(socket, fireResource, time.Second).List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("want only the pod holding fire mana, got %+v", pods)
+	}
+	if pods[0].PodName != "blade" || pods[0].Namespace != "default" {
+		t.Errorf("unexpected pod %s/%s", pods[0].Namespace, pods[0].PodName)
+	}
+	if want := []string{"fire-001", "fire-002", "fire-003"}; !slices.Equal(pods[0].DeviceIDs, want) {
+		t.Errorf("device ids = %v, want %v", pods[0].DeviceIDs, want)
+	}
+}
+
+func TestPodResourcesClientUnavailable(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "missing.sock")
+	if _, err := NewPodResourcesClient(socket, fireResource, time.Second).List(context.Background()); err == nil {
+		t.Fatal("want an error without a kubelet")
+	}
+}
+
+func TestPodWatcherMapsStartedPod(t *testing.T) {
+	pw, fake := newTestWatcher(t, 4)
+	ids := []string{"fire-001", "fire-002"}
+	pending := enchanterPod("blade", "uid-blade", v1.PodPending)
+	pw.onPodAdd(pending)
+	if _, ok := pw.manager.GetAllocation("uid-blade"); ok {
+		t.Fatal("a pod the kubelet reports no devices for must not be mapped")
+	}
+
+	if err := pw.manager.AllocateDevices(ids); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	fake.hold("default", "blade", fireResource, ids...)
+	lists := fake.lists.Load()
+	pw.onPodUpdate(pending, pending)
+	if got := fake.lists.Load(); got != lists {
+		t.Errorf("an update within the phase asked the kubelet %d times", got-lists)
+	}
+
+	running := enchanterPod("blade", "uid-blade", v1.PodRunning)
+	pw.onPodUpdate(pending, running)
+	allocation, ok := pw.manager.GetAllocation("uid-blade")
+	if !ok {
+		t.Fatal("started pod was not mapped")
+	}
+	if !slices.Equal(allocation.DeviceIDs, ids) || allocation.PodName != "blade" || allocation.Namespace != "default" {
+		t.Errorf("unexpected allocation %+v", allocation)
+	}
+	if got := pw.manager.GetAvailableMana(); got != 2 {
+		t.Errorf("available mana = %d, want 2", got)
+	}
+
+	pw.onPodDelete(running)
+	if _, ok := pw.manager.GetAllocation("uid-blade"); ok {
+		t.Error("deleted pod still holds its allocation")
+	}
+	if got := pw.manager.GetAvailableMana(); got != 4 {
+		t.Errorf("available mana after delete = %d, want 4", got)
+	}
+}
+
+func TestPodWatcherMapsPendingPodOnAdd(t *testing.T) {
+	pw, fake := newTestWatcher(t, 4)
+	fake.hold("default", "blade", fireResource, "fire-001")
+
+	pw.onPodAdd(enchanterPod("blade", "uid-blade", v1.PodPending))
+	if _, ok := pw.manager.GetAllocation("uid-blade"); !ok {
+		t.Fatal("a pending pod holding devices was not mapped")
+	}
+}
+
+func TestPodWatcherSkipsPodsWithoutMana(t *testing.T) {
+	pw, fake := newTestWatcher(t, 4)
+	pending := enchanterPod("plain", "uid-plain", v1.PodPending)
+	pending.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+	running := pending.DeepCopy()
+	running.Status.Phase = v1.PodRunning
+
+	pw.onPodAdd(pending)
+	pw.onPodUpdate(pending, running)
+	if got := fake.lists.Load(); got != 0 {
+		t.Errorf("asked the kubelet %d times about a pod without mana", got)
+	}
+}
+
+func TestPodWatcherReleasesPodFailedWhilePending(t *testing.T) {
+	pw, fake := newTestWatcher(t, 4)
+	ids := []string{"fire-001", "fire-002"}
+	if err := pw.manager.AllocateDevices(ids); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	fake.hold("default", "blade", fireResource, ids...)
+
+	// the first update we see of the pod is the failure
+	pending := enchanterPod("blade", "uid-blade", v1.PodPending)
+	pw.onPodUpdate(pending, enchanterPod("blade", "uid-blade", v1.PodFailed))
+	if _, ok := pw.manager.GetAllocation("uid-blade"); ok {
+		t.Error("failed pod still holds its allocation")
+	}
+	if got := pw.manager.GetAvailableMana(); got != 4 {
+		t.Errorf("available mana = %d, want 4", got)
+	}
+}
+
+func TestPodWatcherReleasesPodDeletedWhilePending(t *testing.T) {
+	pw, fake := newTestWatcher(t, 4)
+	if err := pw.manager.AllocateDevices([]string{"fire-003"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	fake.hold("default", "blade", fireResource, "fire-003")
+
+	pw.onPodDelete(enchanterPod("blade", "uid-blade", v1.PodPending))
+	if got := pw.manager.GetAvailableMana(); got != 4 {
+		t.Errorf("available mana = %d, want 4", got)
+	}
+}
+
+func TestPodWatcherMapsPodRunningBeforeStart(t *testing.T) {
+	pw, fake := newTestWatcher(t, 4)
+	// the kubelet handed out devices before the plugin restarted, nothing was allocated through us
+	fake.hold("default", "blade", fireResource, "fire-001")
+
+	pw.onPodAdd(enchanterPod("blade", "uid-blade", v1.PodRunning))
+	if _, ok := pw.manager.GetAllocation("uid-blade"); !ok {
+		t.Fatal("running pod was not mapped")
+	}
+	if got := pw.manager.GetAvailableMana(); got != 3 {
+		t.Errorf("available mana = %d, want 3", got)
+	}
+}
+
+func TestMapAllocationsAgain(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy})
+	ids := []string{"fire-001", "fire-002"}
+	if err := m.AllocateDevices(ids); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+
+	m.MapAllocations("uid-blade", "blade", "default", ids, 1)
+	m.MapAllocations("uid-blade", "blade", "default", ids, 2)
+	if got := m.GetAvailableMana(); got != 2 {
+		t.Fatalf("mapping a pod twice freed its devices, available mana = %d, want 2", got)
+	}
+
+	// the pod holds one device less now, that one is free again
+	m.MapAllocations("uid-blade", "blade", "default", ids[:1], 3)
+	if got := m.GetAvailableMana(); got != 3 {
+		t.Errorf("available mana = %d, want 3", got)
+	}
+	if err := m.ReleaseDevices("uid-blade"); err != nil {
+		t.Fatalf("ReleaseDevices: %v", err)
+	}
+	if got := m.GetAvailableMana(); got != 4 {
+		t.Errorf("available mana after release = %d, want 4", got)
+	}
+}
+
+func TestMapAllocationsTakesOverDevices(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy})
+	if err := m.AllocateDevices([]string{"fire-001", "fire-002"}); err != nil {
+		t.Fatalf("AllocateDevices: %v", err)
+	}
+	m.MapAllocations("uid-blade", "blade", "default", []string{"fire-001", "fire-002"}, 1)
+	// the release of blade was missed and the kubelet handed fire-002 to staff
+	m.MapAllocations("uid-staff", "staff", "default", []string{"fire-002"}, 2)
+
+	blade, ok := m.GetAllocation("uid-blade")
+	if !ok || !slices.Equal(blade.DeviceIDs, []string{"fire-001"}) {
+		t.Fatalf("blade allocation = %+v, want only fire-001", blade)
+	}
+	if err := m.ReleaseDevices("uid-blade"); err != nil {
+		t.Fatalf("ReleaseDevices: %v", err)
+	}
+	if got := m.GetAvailableMana(); got != 3 {
+		t.Errorf("available mana = %d, want 3 while staff holds fire-002", got)
+	}
+
+	// a pod losing its last device is forgotten
+	m.MapAllocations("uid-wand", "wand", "default", []string{"fire-002"}, 3)
+	if _, ok = m.GetAllocation("uid-staff"); ok {
+		t.Error("allocation without devices was kept")
+	}
+}
+
+func TestPodWatcherKeepsAllocationWithoutKubelet(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 2, EnergyType: shared.FireEnergy})
+	pw := &PodWatcher{
+		manager:      m,
+		podResources: NewPodResourcesClient(filepath.Join(t.TempDir(), "missing.sock"), fireResource, time.Second),
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		resourceKey:  fireResource,
+	}
+	pw.onPodAdd(enchanterPod("blade", "uid-blade", v1.PodRunning))
+	if got := len(m.GetAllAllocations()); got != 0 {
+		t.Errorf("allocations = %d, want none", got)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"slices"
+)
+
+// PreferredDevices picks size devices out of available for the kubelet, packed into as few wells as possible.
+// mustInclude is always part of it and the wells it touches are filled up first. After that the smallest well
+// that fits the rest takes it all, if none does the largest one is drained and the rest is looked at again.
+// Ties go to the lower well.
+func (m *ManaGer) PreferredDevices(available, mustInclude []string, size int) []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	picked := make(map[string]bool, size)
+	result := make([]string, 0, size)
+	touched := make(map[int]bool)
+	for _, id := range mustInclude {
+		if picked[id] {
+			continue
+		}
+		picked[id] = true
+		result = append(result, id)
+		if well, ok := m.wells[id]; ok {
+			touched[well] = true
+		}
+	}
+
+	byWell := make(map[int][]string)
+	for _, id := range available {
+		well, ok := m.wells[id]
+		if !ok || picked[id] {
+			continue
+		}
+		picked[id] = true
+		byWell[well] = append(byWell[well], id)
+	}
+	wells := make([]int, 0, len(byWell))
+	for well, ids := range byWell {
+		slices.Sort(ids)
+		wells = append(wells, well)
+	}
+	slices.Sort(wells)
+
+	take := func(well, n int) {
+		n = min(n, len(byWell[well]))
+		result = append(result, byWell[well][:n]...)
+		byWell[well] = byWell[well][n:]
+	}
+	for _, well := range wells {
+		if touched[well] && len(result) < size {
+			take(well, size-len(result))
+		}
+	}
+	for len(result) < size {
+		need := size - len(result)
+		best, largest := -1, -1
+		for _, well := range wells {
+			free := len(byWell[well])
+			if free == 0 {
+				continue
+			}
+			if free >= need && (best < 0 || free < len(byWell[best])) {
+				best = well
+			}
+			if largest < 0 || free > len(byWell[largest]) {
+				largest = well
+			}
+		}
+		switch {
+		case best >= 0:
+			take(best, need)
+		case largest >= 0:
+			take(largest, need)
+		default:
+			return result // the kubelet asked for more than it offered
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/fukaraca/runesmith/shared"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+func TestPreferredDevicesPacksWells(t *testing.T) {
+	m := NewManaGer(ManaConfig{MaxMana: 12, EnergyType: shared.FireEnergy, WellSize: 4})
+	// well 0 has 2 free devices, well 1 has 4 and well 2 has 3
+	available := []string{"fire-003", "fire-004", "fire-005", "fire-006", "fire-007", "fire-008", "fire-010", "fire-011", "fire-012"}
+
+	tests := []struct {
+		name        string
+		mustInclude []string
+		size        int
+		want        []string
+	}{
+		{name: "best fitting well", size: 3, want: []string{"fire-010", "fire-011", "fire-012"}},
+		{name: "smallest well that fits", size: 2, want: []string{"fire-003", "fire-004"}},
+		{name: "largest well first when none fits", size: 6, want: []string{"fire-005", "fire-006", "fire-007", "fire-008", "fire-003", "fire-004"}},
+		{name: "wells of must include first", mustInclude: []string{"fire-010"}, size: 4, want: []string{"fire-010", "fire-011", "fire-012", "fire-003"}},
+		{name: "more than offered", size: 12, want: []string{"fire-005", "fire-006", "fire-007", "fire-008", "fire-010", "fire-011", "fire-012", "fire-003", "fire-004"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.PreferredDevices(available, tt.mustInclude, tt.size)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PreferredDevices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateHonorsKubeletDevices(t *testing.T) {
+	cfg := &Config{Mana: ManaConfig{MaxMana: 4, EnergyType: shared.FireEnergy}}
+	p := &DevicePlugin{
+		config:  cfg,
+		manager: NewManaGer(cfg.Mana),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	resp, err := p.Allocate(context.Background(), &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"fire-001", "fire-003"}}},
+	})
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if got := resp.ContainerResponses[0].Envs["MANA_DEVICE_IDS"]; got != "fire-001,fire-003" {
+		t.Errorf("MANA_DEVICE_IDS = %s, want the devices the kubelet picked", got)
+	}
+	if got := p.manager.GetAvailableMana(); got != 2 {
+		t.Errorf("available mana = %d, want 2", got)
+	}
+	if _, err = p.Allocate(context.Background(), &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"fire-042"}}},
+	}); err == nil {
+		t.Error("want an error for a device we don't serve")
+	}
+}
@@ -2,7 +2,9 @@ package kubeapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/fukaraca/runesmith/components/runesmith-backend/config"
@@ -16,15 +18,25 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var (
+	// ErrEnchantmentNotFound is returned when no Enchantment carries the given artifact id
+	ErrEnchantmentNotFound = errors.New("enchantment not found")
+	// ErrNamespaceNotWatched is returned for a namespace the backend isn't configured for
+	ErrNamespaceNotWatched = errors.New("namespace is not watched")
+)
+
 type Client struct {
-	set        kubernetes.Interface
-	cont       client.Client
-	scheme     *runtime.Scheme
-	Namespace  string
+	set    kubernetes.Interface
+	cont   client.Client
+	scheme *runtime.Scheme
+	// Namespace is the default one, requests that don't name a namespace forge here
+	Namespace string
+	// Namespaces are all the watched namespaces, Namespace included
+	Namespaces []string
 	restConfig *rest.Config
 }
 
-func NewInCluster(namespace string) (*Client, error) {
+func NewInCluster(namespace string, namespaces []string) (*Client, error) {
 	if namespace == "" { // to test locally
 		return nil, nil
 	}
@@ -42,7 +54,35 @@ func NewInCluster(namespace string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Client{set: cs, Namespace: namespace, cont: cont, restConfig: cfg, scheme: sch}, nil
+	return &Client{
+		set:        cs,
+		Namespace:  namespace,
+		Namespaces: watchedNamespaces(namespace, namespaces),
+		cont:       cont,
+		restConfig: cfg,
+		scheme:     sch,
+	}, nil
+}
+
+func watchedNamespaces(namespace string, extra []string) []string {
+	out := []string{namespace}
+	for _, ns := range extra {
+		if ns != "" && !slices.Contains(out, ns) {
+			out = append(out, ns)
+		}
+	}
+	return out
+}
+
+// ResolveNamespace returns the namespace a request works in, the default one when it names none
+func (c *Client) ResolveNamespace(namespace string) (string, error) {
+	if namespace == "" {
+		return c.Namespace, nil
+	}
+	if !slices.Contains(c.Namespaces, namespace) {
+		return "", fmt.Errorf("%w: %s", ErrNamespaceNotWatched, namespace)
+	}
+	return namespace, nil
 }
 
 func (c *Client) CreateEnchantment(
@@ -51,7 +91,7 @@ func (c *Client) CreateEnchantment(
 	enchConfig config.Enchanter,
 	item shared.MagicalItem,
 ) (*enchantmentv1.Enchantment, error) {
-	if c.Namespace == "" {
+	if artifact.Namespace == "" {
 		return nil, fmt.Errorf("namespace must be set")
 	}
 
@@ -69,13 +109,19 @@ func (c *Client) CreateEnchantment(
 		})
 	}
 
+	// components are forged as Enchantments of the same order, see service.Forge
+	deps := make([]enchantmentv1.EnchantmentDependency, 0, len(item.Components))
+	for _, id := range item.Components {
+		deps = append(deps, enchantmentv1.EnchantmentDependency{ItemID: &id})
+	}
+
 	ttl := 5
 	selfReport := true
 
 	enchantment := &enchantmentv1.Enchantment{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: c.generateName(artifact.ID),
-			Namespace:    c.Namespace,
+			Namespace:    artifact.Namespace,
 			Labels:       labels,
 		},
 		Spec: enchantmentv1.EnchantmentSpec{
@@ -91,6 +137,7 @@ func (c *Client) CreateEnchantment(
 			},
 			Cost:       enchConfig.Cost,
 			SelfReport: &selfReport,
+			DependsOn:  deps,
 		},
 		Status: enchantmentv1.EnchantmentStatus{
 			Phase: shared.ScheduledAS, // It doesn't matter anyway
@@ -104,6 +151,43 @@ func (c *Client) CreateEnchantment(
 	return enchantment, nil
 }
 
+// SuspendEnchantment pauses or resumes the Enchantment of the artifact, the operator suspends its jobs
+func (c *Client) SuspendEnchantment(ctx context.Context, namespace string, artifactID int, suspend bool) error {
+	return c.patchEnchantmentSpec(ctx, namespace, artifactID, func(spec *enchantmentv1.EnchantmentSpec) {
+		spec.Suspend = suspend
+	})
+}
+
+// CancelEnchantment stops the Enchantment of the artifact for good, the operator deletes its jobs
+func (c *Client) CancelEnchantment(ctx context.Context, namespace string, artifactID int) error {
+	return c.patchEnchantmentSpec(ctx, namespace, artifactID, func(spec *enchantmentv1.EnchantmentSpec) {
+		spec.Cancel = true
+	})
+}
+
+func (c *Client) patchEnchantmentSpec(ctx context.Context, namespace string, artifactID int,
+	mutate func(spec *enchantmentv1.EnchantmentSpec)) error {
+	var list enchantmentv1.EnchantmentList
+	if err := c.cont.List(ctx, &list,
+		client.InNamespace(namespace),
+		client.MatchingLabels{"artifact-id": strconv.Itoa(artifactID)},
+	); err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		return ErrEnchantmentNotFound
+	}
+	for i := range list.Items {
+		ench := &list.Items[i]
+		patch := client.MergeFrom(ench.DeepCopy())
+		mutate(&ench.Spec)
+		if err := c.cont.Patch(ctx, ench, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *Client) generateName(orderID int) string {
 	return fmt.Sprintf("ench-artifact-%d-", orderID)
 }
@@ -10,6 +10,7 @@ import (
 	enchantv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
 	"github.com/fukaraca/runesmith/shared"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	cache2 "k8s.io/client-go/tools/cache"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -24,16 +25,18 @@ type EnchantmentTracker struct {
 	stopCh   chan struct{}
 	logger   *slog.Logger
 	depot    *artifactory.Artifactory
-	ns       string
+	ns       []string
 }
 
 func NewEnchantmentTracker(c *Client, meta *config.Meta, logger *slog.Logger, art *artifactory.Artifactory) (*EnchantmentTracker, error) {
 	controllerruntime.SetLogger(zap.New())
+	namespaces := make(map[string]cache.Config, len(c.Namespaces))
+	for _, ns := range c.Namespaces {
+		namespaces[ns] = cache.Config{}
+	}
 	cc, err := cache.New(c.restConfig, cache.Options{
-		Scheme: c.scheme,
-		DefaultNamespaces: map[string]cache.Config{
-			c.Namespace: {},
-		},
+		Scheme:            c.scheme,
+		DefaultNamespaces: namespaces,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create cache: %w", err)
@@ -52,7 +55,7 @@ func NewEnchantmentTracker(c *Client, meta *config.Meta, logger *slog.Logger, ar
 		stopCh:   make(chan struct{}),
 		logger:   logger,
 		depot:    art,
-		ns:       c.Namespace,
+		ns:       c.Namespaces,
 	}
 
 	_, err = inf.AddEventHandler(cache2.ResourceEventHandlerFuncs{
@@ -73,7 +76,7 @@ func (t *EnchantmentTracker) Start(ctx context.Context) error {
 	if ok := t.cache.WaitForCacheSync(ctx); !ok {
 		return fmt.Errorf("timed out waiting for enchantment cache to sync")
 	}
-	t.logger.Info("enchantment watcher started", slog.String("namespace", t.ns))
+	t.logger.Info("enchantment watcher started", slog.Any("namespaces", t.ns))
 
 	<-ctx.Done()
 	t.logger.Info("enchantment watcher stopping")
@@ -111,16 +114,30 @@ func (t *EnchantmentTracker) onEnchantUpdate(oldObj, newObj any) {
 		return
 	}
 
-	state := newE.Status.Phase
+	state := stateOf(newE)
 	switch state {
 	case shared.CompletedAS:
 		t.depot.MarkArtifactCompleted(artifactKey(newE), shared.CompletedAS)
 	case shared.FailedAS:
 		t.depot.MarkArtifactCompleted(artifactKey(newE), shared.FailedAS)
+	case shared.DeletedAS:
+		t.depot.MarkArtifactCompleted(artifactKey(newE), shared.DeletedAS)
+	case shared.CancelledAS:
+		t.depot.MarkArtifactCompleted(artifactKey(newE), shared.CancelledAS)
+	case shared.PausedAS:
+		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.PausedAS)
 	case shared.EnchantingAS:
 		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.EnchantingAS)
 	case shared.RequeuedAS:
 		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.RequeuedAS)
+	case shared.PreemptedAS:
+		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.PreemptedAS)
+	case shared.WaitingForGangAS:
+		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.WaitingForGangAS)
+	case shared.BlockedAS:
+		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.BlockedAS)
+	case shared.QuotaExceededAS:
+		t.depot.UpdatePendingArtifact(artifactKey(newE), shared.QuotaExceededAS)
 	case shared.ScheduledAS:
 	}
 
@@ -140,21 +157,58 @@ func (t *EnchantmentTracker) onEnchantDelete(obj any) {
 		return
 	}
 
-	state := ench.Status.Phase
-	if state == shared.CompletedAS {
-		t.depot.MarkArtifactCompleted(artifactKey(ench), shared.CompletedAS)
-	} else if state == shared.FailedAS {
-		t.depot.MarkArtifactCompleted(artifactKey(ench), shared.FailedAS)
-	} else {
-		// unexpected delete ?
-		t.logger.Info("enchantment delete unexpected", slog.String("name", ench.Name), slog.String("last_state", state.String()))
+	state := stateOf(ench)
+	switch state {
+	case shared.CompletedAS, shared.FailedAS, shared.CancelledAS, shared.DeletedAS:
+		t.depot.MarkArtifactCompleted(artifactKey(ench), state)
+	default:
+		// operator finalizer records Deleted, so this is only reached if the finalizer was bypassed
+		t.logger.Info("enchantment delete without terminal state", slog.String("name", ench.Name), slog.String("last_state", state.String()))
 
-		t.depot.MarkArtifactCompleted(artifactKey(ench), shared.FailedAS)
+		t.depot.MarkArtifactCompleted(artifactKey(ench), shared.DeletedAS)
 	}
 
 	t.logger.Info("enchantment delete", slog.String("name", ench.Name), slog.String("last_state", state.String()))
 }
 
+// stateOf derives the artifact state from the enchantment conditions rather than its phase string
+func stateOf(e *enchantv1.Enchantment) shared.EnchantmentPhase {
+	conds := e.Status.Conditions
+	switch {
+	case apimeta.IsStatusConditionTrue(conds, enchantv1.ConditionSucceeded):
+		return shared.CompletedAS
+	case apimeta.IsStatusConditionTrue(conds, enchantv1.ConditionFailed):
+		return shared.FailedAS
+	case apimeta.IsStatusConditionTrue(conds, enchantv1.ConditionProgressing):
+		return shared.EnchantingAS
+	}
+	if c := apimeta.FindStatusCondition(conds, enchantv1.ConditionReady); c != nil {
+		switch c.Reason {
+		case enchantv1.ReasonDeleted:
+			return shared.DeletedAS
+		case enchantv1.ReasonCancelled:
+			return shared.CancelledAS
+		case enchantv1.ReasonDependenciesPending:
+			return shared.BlockedAS
+		case enchantv1.ReasonQuotaExceeded:
+			return shared.QuotaExceededAS
+		}
+	}
+	if c := apimeta.FindStatusCondition(conds, enchantv1.ConditionAdmitted); c != nil {
+		switch c.Reason {
+		case enchantv1.ReasonRequeued:
+			return shared.RequeuedAS
+		case enchantv1.ReasonPreempted:
+			return shared.PreemptedAS
+		case enchantv1.ReasonGangIncomplete:
+			return shared.WaitingForGangAS
+		case enchantv1.ReasonPaused:
+			return shared.PausedAS
+		}
+	}
+	return shared.ScheduledAS
+}
+
 func artifactKey(e *enchantv1.Enchantment) string {
 	return string(e.GetUID())
 }
@@ -0,0 +1,82 @@
+package kubeapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	enchantv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	cache2 "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// RecipeCatalog serves the magical items from the Recipes of the cluster, edits apply without a redeploy
+type RecipeCatalog struct {
+	cache  cache.Cache
+	logger *slog.Logger
+}
+
+func NewRecipeCatalog(c *Client, logger *slog.Logger) (*RecipeCatalog, error) {
+	// Recipes are cluster scoped, so this cache isn't limited to the namespace of the tracker
+	cc, err := cache.New(c.restConfig, cache.Options{Scheme: c.scheme})
+	if err != nil {
+		return nil, fmt.Errorf("create recipe cache: %w", err)
+	}
+
+	inf, err := cc.GetInformer(context.Background(), &enchantv1.Recipe{})
+	if err != nil {
+		return nil, fmt.Errorf("get recipe informer: %w", err)
+	}
+
+	rc := &RecipeCatalog{cache: cc, logger: logger}
+	_, err = inf.AddEventHandler(cache2.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { rc.logChange("recipe add", obj) },
+		UpdateFunc: func(_, newObj any) { rc.logChange("recipe update", newObj) },
+		DeleteFunc: func(obj any) { rc.logChange("recipe delete", obj) },
+	})
+	if err != nil {
+		return nil, fmt.Errorf("event handlers couldn't be added %w", err)
+	}
+	return rc, nil
+}
+
+func (rc *RecipeCatalog) Start(ctx context.Context) error {
+	go rc.cache.Start(ctx) // respects ctx.Done()
+
+	if ok := rc.cache.WaitForCacheSync(ctx); !ok {
+		return fmt.Errorf("timed out waiting for recipe cache to sync")
+	}
+	rc.logger.Info("recipe catalog started", slog.Int("items", len(rc.Items())))
+
+	<-ctx.Done()
+	rc.logger.Info("recipe catalog stopping")
+	return nil
+}
+
+// Items lists the catalog ordered by item id, it is empty until the catalog is started
+func (rc *RecipeCatalog) Items() []shared.MagicalItem {
+	var list enchantv1.RecipeList
+	if err := rc.cache.List(context.Background(), &list); err != nil {
+		rc.logger.Warn("failed to list recipes", slog.Any("error", err))
+		return nil
+	}
+	items := make([]shared.MagicalItem, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, list.Items[i].MagicalItem())
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+func (rc *RecipeCatalog) logChange(msg string, obj any) {
+	if tomb, ok := obj.(cache2.DeletedFinalStateUnknown); ok {
+		obj = tomb.Obj
+	}
+	r, ok := obj.(*enchantv1.Recipe)
+	if !ok {
+		return
+	}
+	rc.logger.Info(msg, slog.String("name", r.Name), slog.Int("item_id", r.Spec.ID))
+}
@@ -4,18 +4,18 @@ import (
 	"strings"
 	"time"
 
-	"github.com/fukaraca/runesmith/shared"
 	logg "github.com/fukaraca/runesmith/shared/log"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server    Server               `mapstructure:"server"`
-	Log       logg.Config          `mapstructure:"log"`
-	Items     []shared.MagicalItem `mapstructure:"magicalItems"`
-	Metadata  Meta                 `mapstructure:"meta"`
-	Plugin    Plugin               `mapstructure:"devicePlugin"`
-	Enchanter Enchanter            `mapstructure:"enchanter"`
+	Server    Server      `mapstructure:"server"`
+	Log       logg.Config `mapstructure:"log"`
+	Metadata  Meta        `mapstructure:"meta"`
+	Plugin    Plugin      `mapstructure:"devicePlugin"`
+	Enchanter Enchanter   `mapstructure:"enchanter"`
+	// Namespaces teams may forge in besides the backend's own, which stays the default
+	Namespaces []string `mapstructure:"namespaces"`
 }
 
 type Server struct {
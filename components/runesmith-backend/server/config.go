@@ -21,11 +21,11 @@ type Server struct {
 
 func NewServer(cfg *config.Config, engine *gin.Engine, logger *slog.Logger) (*Server, error) {
 	v1 := engine.Group(V1)
-	apiClient, err := kubeapi.NewInCluster(cfg.Metadata.Namespace)
+	apiClient, err := kubeapi.NewInCluster(cfg.Metadata.Namespace, cfg.Namespaces)
 	if err != nil {
 		return nil, err
 	}
-	svc, err := service.New(apiClient, cfg.Items, cfg.Plugin, cfg.Enchanter, &cfg.Metadata, logger)
+	svc, err := service.New(apiClient, cfg.Plugin, cfg.Enchanter, &cfg.Metadata, logger)
 	if err != nil {
 		return nil, err
 	}
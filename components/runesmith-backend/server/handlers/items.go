@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/fukaraca/runesmith/components/runesmith-backend/api/kubeapi"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,9 +16,14 @@ func (r *Rest) GetItemsList(c *gin.Context) {
 	})
 }
 
+// Forge orders a random item, the optional namespace query parameter picks one of the watched namespaces
 func (r *Rest) Forge(c *gin.Context) {
-	name, err := r.svc.Forge(c.Request.Context())
+	name, err := r.svc.Forge(c.Request.Context(), c.Query("namespace"))
 	if err != nil {
+		if errors.Is(err, kubeapi.ErrNamespaceNotWatched) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
 		return
@@ -25,6 +34,42 @@ func (r *Rest) Forge(c *gin.Context) {
 func (r *Rest) Artifacts(c *gin.Context) {
 	completed := c.Query("completed") == "true"
 	c.JSON(http.StatusOK, gin.H{
-		"artifacts": r.svc.GetArtifacts(completed),
+		"artifacts": r.svc.GetArtifacts(completed, c.Query("namespace")),
 	})
 }
+
+func (r *Rest) CancelArtifact(c *gin.Context) {
+	r.artifactOperation(c, "cancel", r.svc.CancelArtifact)
+}
+
+func (r *Rest) PauseArtifact(c *gin.Context) {
+	r.artifactOperation(c, "pause", r.svc.PauseArtifact)
+}
+
+func (r *Rest) ResumeArtifact(c *gin.Context) {
+	r.artifactOperation(c, "resume", r.svc.ResumeArtifact)
+}
+
+// artifactOperation requests op on the artifact of the path in the namespace of the query, the new status
+// shows up on /artifacts once the operator acted on it
+func (r *Rest) artifactOperation(c *gin.Context, name string, op func(ctx context.Context, namespace string, id int) error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "artifact id must be a number"})
+		return
+	}
+	if err = op(c.Request.Context(), c.Query("namespace"), id); err != nil {
+		if errors.Is(err, kubeapi.ErrEnchantmentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, kubeapi.ErrNamespaceNotWatched) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"artifact_id": id, "operation": name})
+}
@@ -10,8 +10,11 @@ import (
 
 type ItemsService interface {
 	AllItems() []shared.MagicalItem
-	Forge(ctx context.Context) (string, error)
-	GetArtifacts(completed bool) []artifactory.Artifact
+	Forge(ctx context.Context, namespace string) (string, error)
+	GetArtifacts(completed bool, namespace string) []artifactory.Artifact
+	CancelArtifact(ctx context.Context, namespace string, id int) error
+	PauseArtifact(ctx context.Context, namespace string, id int) error
+	ResumeArtifact(ctx context.Context, namespace string, id int) error
 	Status(ctx context.Context) ([]shared.NodeStatus, error)
 }
 
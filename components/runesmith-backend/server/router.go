@@ -26,6 +26,9 @@ func (s *Server) bindRoutes() {
 	s.router.GET("/items", r.GetItemsList)
 	s.router.POST("/forge", middlewares.RateLimiterMw(), r.Forge)
 	s.router.GET("/artifacts", r.Artifacts)
+	s.router.POST("/artifacts/:id/cancel", r.CancelArtifact)
+	s.router.POST("/artifacts/:id/pause", r.PauseArtifact)
+	s.router.POST("/artifacts/:id/resume", r.ResumeArtifact)
 
 	s.router.GET("/status", r.Status)
 }
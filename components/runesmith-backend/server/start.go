@@ -49,6 +49,12 @@ func Start(cfg *config.Config) error {
 		}
 	}()
 
+	go func() {
+		if errInner := server.Service.Catalog.Start(context.Background()); errInner != nil {
+			log.Fatalf("recipe catalog failed %v", errInner)
+		}
+	}()
+
 	if err = httpServer.ListenAndServe(); err != nil {
 		if errors.Is(err, http.ErrServerClosed) {
 			logger.Warn("Server closed under request")
@@ -11,6 +11,7 @@ import (
 // Artifact is produce order of the item.
 type Artifact struct {
 	ID        int
+	Namespace string
 	ItemID    int
 	ItemName  string
 	TaskID    string
@@ -1,10 +1,50 @@
 package service
 
-import "github.com/fukaraca/runesmith/components/runesmith-backend/service/artifactory"
+import (
+	"context"
 
-func (s *Service) GetArtifacts(completed bool) []artifactory.Artifact {
+	"github.com/fukaraca/runesmith/components/runesmith-backend/service/artifactory"
+)
+
+// GetArtifacts lists the artifacts of the namespace, of every watched namespace when it is empty
+func (s *Service) GetArtifacts(completed bool, namespace string) []artifactory.Artifact {
+	arts := s.depot.Pending()
 	if completed {
-		return s.depot.Done()
+		arts = s.depot.Done()
+	}
+	if namespace == "" {
+		return arts
+	}
+	out := make([]artifactory.Artifact, 0, len(arts))
+	for _, art := range arts {
+		if art.Namespace == namespace {
+			out = append(out, art)
+		}
+	}
+	return out
+}
+
+// CancelArtifact stops the order, it moves to done once the operator reports Cancelled
+func (s *Service) CancelArtifact(ctx context.Context, namespace string, id int) error {
+	ns, err := s.kubeApi.ResolveNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	return s.kubeApi.CancelEnchantment(ctx, ns, id)
+}
+
+func (s *Service) PauseArtifact(ctx context.Context, namespace string, id int) error {
+	ns, err := s.kubeApi.ResolveNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	return s.kubeApi.SuspendEnchantment(ctx, ns, id, true)
+}
+
+func (s *Service) ResumeArtifact(ctx context.Context, namespace string, id int) error {
+	ns, err := s.kubeApi.ResolveNamespace(namespace)
+	if err != nil {
+		return err
 	}
-	return s.depot.Pending()
+	return s.kubeApi.SuspendEnchantment(ctx, ns, id, false)
 }
@@ -2,19 +2,34 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/fukaraca/runesmith/components/runesmith-backend/api/kubeapi"
 	"github.com/fukaraca/runesmith/components/runesmith-backend/server/middlewares"
 	"github.com/fukaraca/runesmith/components/runesmith-backend/service/artifactory"
 	"github.com/fukaraca/runesmith/shared"
 )
 
-func (s *Service) Forge(ctx context.Context) (string, error) {
+// Forge orders a random item in the namespace, the default one when it is empty
+func (s *Service) Forge(ctx context.Context, namespace string) (string, error) {
 	logger := middlewares.GetLoggerFromContext(ctx)
+	ns, err := s.kubeApi.ResolveNamespace(namespace)
+	if err != nil {
+		return "", err
+	}
+	item, err := s.randomItem()
+	if err != nil {
+		return "", err
+	}
+	tree, err := s.componentTree(item)
+	if err != nil {
+		return "", err
+	}
 	id := s.nextID()
-	item := s.randomItem()
 	art := &artifactory.Artifact{
 		ID:        id,
+		Namespace: ns,
 		ItemID:    item.ID,
 		ItemName:  item.Name,
 		CreatedAt: time.Now(),
@@ -22,8 +37,16 @@ func (s *Service) Forge(ctx context.Context) (string, error) {
 		Status:    shared.ScheduledAS,
 	}
 
+	// the components share the artifact, only the Enchantment of the item itself is tracked
+	for _, component := range tree[:len(tree)-1] {
+		if _, err = s.kubeApi.CreateEnchantment(ctx, art, s.enchanter, component); err != nil {
+			s.abandonTree(ctx, art)
+			return "", err
+		}
+	}
 	enchantment, err := s.kubeApi.CreateEnchantment(ctx, art, s.enchanter, item)
 	if err != nil {
+		s.abandonTree(ctx, art)
 		return "", err
 	}
 
@@ -33,9 +56,20 @@ func (s *Service) Forge(ctx context.Context) (string, error) {
 
 	logger.Info("forge scheduled",
 		"artifact_id", art.ID,
+		"namespace", art.Namespace,
 		"item_id", art.ItemID,
+		"components", len(tree)-1,
 		"enchantment_name", enchantment.GetName(),
 		"enchantment_uid", string(enchantment.GetUID()),
 	)
 	return enchantment.GetName(), nil
 }
+
+// abandonTree cancels the components created for a forge request that couldn't be completed
+func (s *Service) abandonTree(ctx context.Context, art *artifactory.Artifact) {
+	err := s.kubeApi.CancelEnchantment(ctx, art.Namespace, art.ID)
+	if err != nil && !errors.Is(err, kubeapi.ErrEnchantmentNotFound) {
+		middlewares.GetLoggerFromContext(ctx).Warn("failed to cancel components",
+			"artifact_id", art.ID, "error", err)
+	}
+}
@@ -1,15 +1,65 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"math/rand"
 
 	"github.com/fukaraca/runesmith/shared"
 )
 
+// ErrEmptyCatalog is returned by Forge while no Recipe exists in the cluster
+var ErrEmptyCatalog = errors.New("no recipes in the catalog")
+
+// maxComponentDepth bounds the component tree of a single forge request
+const maxComponentDepth = 5
+
 func (s *Service) AllItems() []shared.MagicalItem {
-	return s.Items
+	return s.Catalog.Items()
 }
 
-func (s *Service) randomItem() shared.MagicalItem {
-	return s.Items[rand.Intn(len(s.Items))]
+func (s *Service) randomItem() (shared.MagicalItem, error) {
+	items := s.Catalog.Items()
+	if len(items) == 0 {
+		return shared.MagicalItem{}, ErrEmptyCatalog
+	}
+	return items[rand.Intn(len(items))], nil
+}
+
+// componentTree expands the components of item depth first. Every item comes after its components and
+// item itself is the last one, so creating them in order never leaves a dependency uncreated for long.
+func (s *Service) componentTree(item shared.MagicalItem) ([]shared.MagicalItem, error) {
+	catalog := make(map[int]shared.MagicalItem)
+	for _, it := range s.Catalog.Items() {
+		catalog[it.ID] = it
+	}
+
+	var tree []shared.MagicalItem
+	path := make(map[int]bool)
+	var expand func(it shared.MagicalItem, depth int) error
+	expand = func(it shared.MagicalItem, depth int) error {
+		if depth > maxComponentDepth {
+			return fmt.Errorf("components of item %d are nested deeper than %d", item.ID, maxComponentDepth)
+		}
+		if path[it.ID] {
+			return fmt.Errorf("item %d is a component of itself", it.ID)
+		}
+		path[it.ID] = true
+		for _, id := range it.Components {
+			component, ok := catalog[id]
+			if !ok {
+				return fmt.Errorf("component %d of item %d isn't in the catalog", id, it.ID)
+			}
+			if err := expand(component, depth+1); err != nil {
+				return err
+			}
+		}
+		delete(path, it.ID)
+		tree = append(tree, it)
+		return nil
+	}
+	if err := expand(item, 0); err != nil {
+		return nil, err
+	}
+	return tree, nil
 }
@@ -9,12 +9,11 @@ import (
 	"github.com/fukaraca/runesmith/components/runesmith-backend/api/nodes"
 	"github.com/fukaraca/runesmith/components/runesmith-backend/config"
 	"github.com/fukaraca/runesmith/components/runesmith-backend/service/artifactory"
-	"github.com/fukaraca/runesmith/shared"
 )
 
 type Service struct {
 	depot        *artifactory.Artifactory
-	Items        []shared.MagicalItem
+	Catalog      *kubeapi.RecipeCatalog
 	counter      atomic.Uint64
 	kubeApi      *kubeapi.Client
 	plugin       config.Plugin
@@ -27,14 +26,18 @@ func (s *Service) nextID() int {
 	return int(s.counter.Add(1))
 }
 
-func New(api *kubeapi.Client, items []shared.MagicalItem, plugin config.Plugin, enchanter config.Enchanter, meta *config.Meta, logger *slog.Logger) (*Service, error) {
+func New(api *kubeapi.Client, plugin config.Plugin, enchanter config.Enchanter, meta *config.Meta, logger *slog.Logger) (*Service, error) {
 	art := artifactory.NewArtifactory()
 	tracker, err := kubeapi.NewEnchantmentTracker(api, meta, logger, art)
 	if err != nil {
 		return nil, err
 	}
+	catalog, err := kubeapi.NewRecipeCatalog(api, logger)
+	if err != nil {
+		return nil, err
+	}
 	s := &Service{
-		Items:     items,
+		Catalog:   catalog,
 		depot:     art,
 		kubeApi:   api,
 		plugin:    plugin,
@@ -4,14 +4,22 @@ import { Github, Linkedin, Flame, Snowflake, Sparkles, ExternalLink, Info, X, Mo
 // ---------- Types from backend ----------
 export type ArtifactStatus =
     | "Scheduled"
+    | "Blocked"
+    | "QuotaExceeded"
+    | "WaitingForGang"
     | "Requeued"
+    | "Preempted"
     | "Failed"
     | "Enchanting"
     | "Completed"
+    | "Deleted"
+    | "Paused"
+    | "Cancelled"
     | string;
 
 export interface Artifact {
     ID: number;
+    Namespace: string;
     ItemID: number;
     ItemName: string;
     TaskID: string;
@@ -237,7 +245,9 @@ const NodeCard: React.FC<{ s: NodeStatus }>= ({ s }) => {
 };
 
 // ---------- Lists ----------
-const ArtifactsTable: React.FC<{ title: string; artifacts: Artifact[] }> = ({ title, artifacts }) => (
+type ArtifactAction = "pause" | "resume" | "cancel";
+
+const ArtifactsTable: React.FC<{ title: string; artifacts: Artifact[]; onAction?: (id: number, action: ArtifactAction) => void }> = ({ title, artifacts, onAction }) => (
     <div className="rounded-2xl border border-slate-200 dark:border-slate-700 p-4 shadow-sm bg-white dark:bg-slate-900">
         <div className="mb-3 text-sm font-semibold">{title}</div>
         <div className="overflow-x-auto">
@@ -249,6 +259,7 @@ const ArtifactsTable: React.FC<{ title: string; artifacts: Artifact[] }> = ({ ti
                     <th className="px-2 py-1 w-[22rem] md:w-[30rem]">ItemName</th>
                     <th className="px-2 py-1">Status</th>
                     <th className="px-2 py-1">Created</th>
+                    {onAction && <th className="px-2 py-1">Actions</th>}
                 </tr>
                 </thead>
                 <tbody>
@@ -268,11 +279,19 @@ const ArtifactsTable: React.FC<{ title: string; artifacts: Artifact[] }> = ({ ti
                         <td className="px-2 py-1 text-slate-500 dark:text-slate-400">
                             {new Date(a.CreatedAt).toLocaleString()}
                         </td>
+                        {onAction && (
+                            <td className="px-2 py-1 space-x-2 whitespace-nowrap">
+                                {a.Status === "Paused"
+                                    ? <button onClick={() => onAction(a.ID, "resume")} className="text-xs underline underline-offset-4">Resume</button>
+                                    : <button onClick={() => onAction(a.ID, "pause")} className="text-xs underline underline-offset-4">Pause</button>}
+                                <button onClick={() => onAction(a.ID, "cancel")} className="text-xs underline underline-offset-4 text-red-700 dark:text-red-400">Cancel</button>
+                            </td>
+                        )}
                     </tr>
                 ))}
                 {artifacts.length === 0 && (
                     <tr>
-                        <td colSpan={5} className="px-2 py-3 text-center text-slate-400 dark:text-slate-500">
+                        <td colSpan={onAction ? 6 : 5} className="px-2 py-3 text-center text-slate-400 dark:text-slate-500">
                             Nothing here yet.
                         </td>
                     </tr>
@@ -353,6 +372,20 @@ const App: React.FC = () => {
         }
     }, [fetchArtifacts, fetchStatus, push]);
 
+    const artifactAction = useCallback(async (id: number, action: ArtifactAction) => {
+        try {
+            const r = await fetch(`${API}/artifacts/${id}/${action}`, { method: "POST" });
+            if (!r.ok) {
+                push(`${action} of artifact ${id} failed (HTTP ${r.status}).`);
+                return;
+            }
+            push(`Artifact ${id}: ${action} requested`);
+            await fetchArtifacts();
+        } catch {
+            push(`${action} request failed`);
+        }
+    }, [fetchArtifacts, push]);
+
     useEffect(() => { fetchStatus(); fetchArtifacts(); }, [fetchStatus, fetchArtifacts]);
 
     useEffect(() => {
@@ -384,7 +417,7 @@ const App: React.FC = () => {
                 </section>
 
                 <section className="mb-6">
-                    <ArtifactsTable title="Artifacts in Production" artifacts={pending} />
+                    <ArtifactsTable title="Artifacts in Production" artifacts={pending} onAction={artifactAction} />
                 </section>
                 <section className="mb-6">
                     <ArtifactsTable title="Completed Orders" artifacts={completed} />
@@ -448,7 +481,7 @@ priority: 2`}</code></pre>
                         <li><strong>Forge</strong>: submit a new Enchantment (random item).</li>
                         <li><strong>List of possible items</strong>: view the catalog (requirements per energy).</li>
                         <li><strong>Artifacts</strong>: see live orders and statuses
-                            (<code>Scheduled</code>, <code>Enchanting</code>, <code>Requeued</code>, <code>Failed</code>, <code>Completed</code>).</li>
+                            (<code>Scheduled</code>, <code>Blocked</code>, <code>QuotaExceeded</code>, <code>WaitingForGang</code>, <code>Enchanting</code>, <code>Requeued</code>, <code>Preempted</code>, <code>Paused</code>, <code>Failed</code>, <code>Completed</code>, <code>Cancelled</code>). Items with components stay <code>Blocked</code> until their components are forged, and orders over the mana budget of their namespace wait in <code>QuotaExceeded</code>. In-flight orders can be paused, resumed or cancelled.</li>
                         <li><strong>Nodes</strong>: real-time availability and allocation per node and energy type.</li>
                     </ul>
 
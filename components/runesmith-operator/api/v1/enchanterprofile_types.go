@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnchanterPodOverlay is merged onto the pod template the operator generates for every requirement.
+type EnchanterPodOverlay struct {
+	// Labels and Annotations are added to the pod, the operator's own labels win on conflict.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// NodeSelector is merged with the energy node selector.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are appended to the energy toleration.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// +optional
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// Env is appended to the enchanter container's environment.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// EnchanterProfileSpec defines how the enchanter Jobs of an Enchantment are generated.
+type EnchanterProfileSpec struct {
+	// Image of the enchanter container, the manager's --enchanter-image is used when empty.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +kubebuilder:default=IfNotPresent
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// QueueName is the Kueue LocalQueue the Jobs are submitted to. When empty the queue is taken from the
+	// enchantment.runesmith.io/local-queue annotation or label of the Enchantment's namespace, and the
+	// manager's --default-local-queue otherwise.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	QueueName string `json:"queueName,omitempty"`
+
+	// MaxExecTimeSeconds is handed to Kueue, a Job running longer is evicted.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=360
+	MaxExecTimeSeconds int32 `json:"maxExecTimeSeconds,omitempty"`
+
+	// Port the enchanter serves its health endpoints on.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=8080
+	Port int32 `json:"port,omitempty"`
+
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// LivenessProbe and ReadinessProbe replace the defaults that poll /healthz and /readyz on Port.
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Resources of the enchanter container. The mana limit of the requirement is always added on top.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// +optional
+	Template EnchanterPodOverlay `json:"template,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=eprof
+// +kubebuilder:printcolumn:name="Queue",type=string,JSONPath=`.spec.queueName`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Max Exec",type=integer,JSONPath=`.spec.maxExecTimeSeconds`
+
+// EnchanterProfile is the Schema for the enchanterprofiles API
+type EnchanterProfile struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines how enchanter Jobs are generated
+	// +required
+	Spec EnchanterProfileSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnchanterProfileList contains a list of EnchanterProfile
+type EnchanterProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnchanterProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnchanterProfile{}, &EnchanterProfileList{})
+}
@@ -28,14 +28,13 @@ type EnchantmentSpecArtifact struct {
 	ID int `json:"id"`
 
 	// +kubebuilder:validation:MinLength=1
-	Name string `json:"name"`
+	Name string `json:"name,omitempty"`
 
-	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Enum=Common;Rare;Epic;Legendary
 	// +kubebuilder:validation:Type=string
-	Tier shared.Tier `json:"tier"`
+	Tier shared.Tier `json:"tier,omitempty"`
 
-	Requirements []EnchantmentSpecArtifactRequirement `json:"requirements"`
+	Requirements []EnchantmentSpecArtifactRequirement `json:"requirements,omitempty"`
 
 	Priority int `json:"priority"`
 }
@@ -45,9 +44,11 @@ type EnchantmentSpecArtifactRequirement struct {
 	// +kubebuilder:validation:Type=string
 	EnergyType shared.Elemental `json:"energyType"`
 
+	// ResourceName defaults to the device plugin resource of EnergyType.
+	// +optional
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Type=string
-	ResourceName shared.Resource `json:"resourceName"`
+	ResourceName shared.Resource `json:"resourceName,omitempty"`
 
 	// +kubebuilder:validation:Minimum=1
 	Limit int `json:"limit"`
@@ -58,7 +59,35 @@ type EnchantmentRetentionPolicy struct {
 	TTLSecondsAfterFinished *int `json:"ttlSecondsAfterFinished,omitempty"`
 }
 
+// EnchantmentRetryPolicy recreates the Job of a failed requirement instead of failing the whole Enchantment.
+type EnchantmentRetryPolicy struct {
+	// MaxRetries is how many times each requirement's Job may be recreated after it fails.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	MaxRetries int `json:"maxRetries"`
+
+	// BackoffSeconds is the delay before the first retry, it doubles on every following one.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=10
+	BackoffSeconds int `json:"backoffSeconds,omitempty"`
+}
+
+// EnchantmentDependency names what has to complete before the Enchantment may start.
+// +kubebuilder:validation:XValidation:rule="has(self.name) != has(self.itemId)",message="exactly one of name and itemId must be set"
+type EnchantmentDependency struct {
+	// Name of another Enchantment in the same namespace.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name,omitempty"`
+
+	// ItemID waits for every other Enchantment of the same orderId that forges this catalog item.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ItemID *int `json:"itemId,omitempty"`
+}
+
 // EnchantmentSpec defines the desired state of Enchantment
+// +kubebuilder:validation:XValidation:rule="has(self.recipe) || (has(self.artifact) && has(self.artifact.name) && has(self.artifact.tier) && has(self.artifact.requirements))",message="artifact name, tier and requirements are required unless a recipe is referenced"
 type EnchantmentSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
@@ -67,16 +96,158 @@ type EnchantmentSpec struct {
 
 	Retention EnchantmentRetentionPolicy `json:"retention,omitempty"`
 
+	// RetryPolicy is unset by default, a failed Job then fails the Enchantment.
+	// +optional
+	RetryPolicy *EnchantmentRetryPolicy `json:"retryPolicy,omitempty"`
+
 	// +kubebuilder:validation:Minimum=1
 	OrderID int `json:"orderId"`
 
-	Artifact EnchantmentSpecArtifact `json:"artifact"`
+	// Artifact describes the item inline, it is filled from the Recipe when one is referenced.
+	// +optional
+	Artifact EnchantmentSpecArtifact `json:"artifact,omitempty"`
+
+	// Recipe names the Recipe the artifact and its requirements are resolved from.
+	// +optional
+	Recipe string `json:"recipe,omitempty"`
 
 	// +kubebuilder:validation:Minimum=1
 	Cost int `json:"cost"`
 
 	// +kubebuilder:default=true
 	SelfReport *bool `json:"selfReport,omitempty"`
+
+	// Profile names the EnchanterProfile the Jobs are generated from, the manager's default is used when empty.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// DeadlineSeconds bounds the wall-clock time from creation until the Enchantment finishes, it fails
+	// with DeadlineExceeded afterwards.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DeadlineSeconds *int `json:"deadlineSeconds,omitempty"`
+
+	// QueueTimeoutSeconds bounds each stretch spent waiting for admission (Scheduled, WaitingForGang,
+	// Requeued or Preempted), it fails with QueueTimeout afterwards.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	QueueTimeoutSeconds *int `json:"queueTimeoutSeconds,omitempty"`
+
+	// Suspend pauses the Enchantment, its Jobs are suspended until it is set back to false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Cancel stops the Enchantment for good, its Jobs are deleted and the status is kept until the ttl.
+	// +optional
+	Cancel bool `json:"cancel,omitempty"`
+
+	// DependsOn keeps the Enchantment Blocked until all of its dependencies are Completed, it fails once
+	// any of them fails, they depend on it in turn, or one is still missing after the manager's
+	// --missing-dependency-timeout.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	DependsOn []EnchantmentDependency `json:"dependsOn,omitempty"`
+
+	// GangScheduling holds the pods of every requirement Job behind a scheduling gate until Kueue admitted
+	// all of them, so no mana is burned on a partially admitted Enchantment. Enable waitForPodsReady in
+	// Kueue to have it release the quota of a gang that can't complete.
+	// +optional
+	GangScheduling bool `json:"gangScheduling,omitempty"`
+}
+
+// Condition types reported on EnchantmentStatus.Conditions.
+const (
+	// ConditionJobsCreated is True once every requirement has its Job.
+	ConditionJobsCreated = "JobsCreated"
+	// ConditionAdmitted is True while Kueue lets all Jobs run, False while any of them is suspended.
+	ConditionAdmitted = "Admitted"
+	// ConditionProgressing is True while Jobs are actively enchanting.
+	ConditionProgressing = "Progressing"
+	// ConditionSucceeded is True once every Job has succeeded.
+	ConditionSucceeded = "Succeeded"
+	// ConditionFailed is True once the Enchantment can no longer complete.
+	ConditionFailed = "Failed"
+	// ConditionDependenciesMet is True once every spec.dependsOn entry completed, it is not checked again.
+	ConditionDependenciesMet = "DependenciesMet"
+	// ConditionReady summarizes the outcome so that `kubectl wait --for=condition=Ready` works.
+	ConditionReady = "Ready"
+	// ConditionBudgetCharged is False while the spend of the completed Enchantment is still to be charged to
+	// the ManaBudgets, True once it was.
+	ConditionBudgetCharged = "BudgetCharged"
+)
+
+// Condition reasons reported on EnchantmentStatus.Conditions.
+const (
+	ReasonJobsCreated         = "JobsCreated"
+	ReasonJobCreateFailed     = "JobCreateFailed"
+	ReasonProfileNotFound     = "ProfileNotFound"
+	ReasonWaitingForAdmission = "WaitingForAdmission"
+	ReasonAdmitted            = "Admitted"
+	ReasonRequeued            = "Requeued"
+	ReasonPreempted           = "Preempted"
+	ReasonGangIncomplete      = "GangIncomplete"
+	ReasonDeadlineExceeded    = "DeadlineExceeded"
+	ReasonQueueTimeout        = "QueueTimeout"
+	ReasonPaused              = "Paused"
+	ReasonCancelled           = "Cancelled"
+	ReasonRecipeNotFound      = "RecipeNotFound"
+	ReasonRecipeResolved      = "RecipeResolved"
+	ReasonDependenciesPending = "DependenciesPending"
+	ReasonDependenciesMet     = "DependenciesMet"
+	ReasonDependencyFailed    = "DependencyFailed"
+	ReasonDependencyCycle     = "DependencyCycle"
+	ReasonQuotaExceeded       = "QuotaExceeded"
+	ReasonJobsRunning         = "JobsRunning"
+	ReasonJobsSucceeded       = "JobsSucceeded"
+	ReasonJobFailed           = "JobFailed"
+	ReasonRetrying            = "Retrying"
+	ReasonJobsVanished        = "JobsVanished"
+	ReasonDeleted             = "Deleted"
+	ReasonSpendCharged        = "SpendCharged"
+	ReasonChargePending       = "ChargePending"
+)
+
+// RequirementJobState is the state of the Job serving a single requirement.
+// +kubebuilder:validation:Enum=Suspended;Preempted;Admitted;Running;Succeeded;Failed;Retrying
+type RequirementJobState string
+
+const (
+	RequirementSuspended RequirementJobState = "Suspended"
+	// RequirementPreempted means Kueue evicted the Job to make room for a higher priority workload.
+	RequirementPreempted RequirementJobState = "Preempted"
+	RequirementAdmitted  RequirementJobState = "Admitted"
+	RequirementRunning   RequirementJobState = "Running"
+	RequirementSucceeded RequirementJobState = "Succeeded"
+	RequirementFailed    RequirementJobState = "Failed"
+	// RequirementRetrying means the Job failed and is recreated once the backoff passes.
+	RequirementRetrying RequirementJobState = "Retrying"
+)
+
+// EnchantmentRequirementStatus is the observed state of the Job serving one requirement.
+type EnchantmentRequirementStatus struct {
+	// +kubebuilder:validation:Enum=fire;frost;arcane
+	// +kubebuilder:validation:Type=string
+	EnergyType shared.Elemental `json:"energyType"`
+
+	JobName    string              `json:"jobName,omitempty"`
+	State      RequirementJobState `json:"state,omitempty"`
+	StartTime  *metav1.Time        `json:"startTime,omitempty"`
+	FinishTime *metav1.Time        `json:"finishTime,omitempty"`
+	NodeName   string              `json:"nodeName,omitempty"`
+
+	// Attempts counts the Jobs created for this requirement, retries included.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Workload is the Kueue Workload of the Job, the fields below are read from it.
+	Workload      string       `json:"workload,omitempty"`
+	ClusterQueue  string       `json:"clusterQueue,omitempty"`
+	Flavor        string       `json:"flavor,omitempty"`
+	AdmissionTime *metav1.Time `json:"admissionTime,omitempty"`
+
+	// QueuePosition is the 1-based position among the pending workloads of the ClusterQueue, across every
+	// namespace feeding it, estimated the way Kueue orders them: higher priority first, then older first.
+	// +optional
+	QueuePosition *int32 `json:"queuePosition,omitempty"`
 }
 
 // EnchantmentStatus defines the observed state of Enchantment.
@@ -84,10 +255,14 @@ type EnchantmentStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// +kubebuilder:validation:Enum=Scheduled;Enchanting;Failed;Completed;Requeued
+	// +kubebuilder:validation:Enum=Scheduled;Blocked;QuotaExceeded;WaitingForGang;Enchanting;Failed;Completed;Requeued;Preempted;Paused;Cancelled;Deleted
 	// +kubebuilder:validation:Type=string
 	Phase shared.EnchantmentPhase `json:"phase,omitempty"`
 
+	// Artifact is spec.recipe resolved at the first reconcile, later Recipe edits don't change it.
+	// +optional
+	Artifact *EnchantmentSpecArtifact `json:"artifact,omitempty"`
+
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 	ExpiresAt      *metav1.Time `json:"expiresAt,omitempty"`
 
@@ -95,12 +270,31 @@ type EnchantmentStatus struct {
 	FailedJobs    int    `json:"failedJobs"`
 	ActiveJobs    int    `json:"activeJobs"`
 	Progress      string `json:"progress,omitempty"`
+
+	// Requirements breaks the progress down per energy type.
+	// +optional
+	// +listType=map
+	// +listMapKey=energyType
+	Requirements []EnchantmentRequirementStatus `json:"requirements,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the latest observations of the Enchantment's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Jobs",type=string,JSONPath=`.status.progress`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Success",type=integer,priority=1,JSONPath=`.status.succeededJobs`
 // +kubebuilder:printcolumn:name="Fail",type=integer,priority=1,JSONPath=`.status.failedJobs`
 // +kubebuilder:printcolumn:name="Active",type=integer,priority=1,JSONPath=`.status.activeJobs`
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/fukaraca/runesmith/shared"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnchantmentBatchSelector picks the Recipe of every child, a random Recipe of the catalog is picked when empty.
+// +kubebuilder:validation:XValidation:rule="!(has(self.itemId) && has(self.tier))",message="itemId and tier are mutually exclusive"
+type EnchantmentBatchSelector struct {
+	// ItemID forges the Recipe with this catalog id only.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ItemID *int `json:"itemId,omitempty"`
+
+	// Tier forges a random Recipe of the tier for every child.
+	// +optional
+	// +kubebuilder:validation:Enum=Common;Rare;Epic;Legendary
+	// +kubebuilder:validation:Type=string
+	Tier shared.Tier `json:"tier,omitempty"`
+}
+
+// EnchantmentBatchTemplate is copied into the spec of every child Enchantment.
+type EnchantmentBatchTemplate struct {
+	// +kubebuilder:validation:Minimum=1
+	Cost int `json:"cost"`
+
+	// +optional
+	RetryPolicy *EnchantmentRetryPolicy `json:"retryPolicy,omitempty"`
+
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// +optional
+	GangScheduling bool `json:"gangScheduling,omitempty"`
+}
+
+// EnchantmentBatchSpec defines the desired state of EnchantmentBatch
+type EnchantmentBatchSpec struct {
+	// OrderID is shared by every child Enchantment of the batch.
+	// +kubebuilder:validation:Minimum=1
+	OrderID int `json:"orderId"`
+
+	// Count is the number of Enchantments to forge.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	Count int `json:"count"`
+
+	// Parallelism caps the children that are unfinished at the same time.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// +optional
+	Selector EnchantmentBatchSelector `json:"selector,omitempty"`
+
+	Template EnchantmentBatchTemplate `json:"template"`
+}
+
+// EnchantmentBatchPhase is the summarized state of a batch.
+// +kubebuilder:validation:Enum=Running;Completed;Failed
+type EnchantmentBatchPhase string
+
+const (
+	BatchRunning   EnchantmentBatchPhase = "Running"
+	BatchCompleted EnchantmentBatchPhase = "Completed"
+	// BatchFailed means every child finished and at least one of them didn't complete.
+	BatchFailed EnchantmentBatchPhase = "Failed"
+)
+
+// Condition reasons reported on EnchantmentBatchStatus.Conditions.
+const (
+	ReasonNoMatchingRecipe  = "NoMatchingRecipe"
+	ReasonChildrenRunning   = "ChildrenRunning"
+	ReasonChildrenSucceeded = "ChildrenSucceeded"
+	ReasonChildFailed       = "ChildFailed"
+)
+
+// EnchantmentBatchStatus defines the observed state of EnchantmentBatch.
+type EnchantmentBatchStatus struct {
+	Phase EnchantmentBatchPhase `json:"phase,omitempty"`
+
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Active, Succeeded and Failed count the children by state, Cancelled children count as failed.
+	Active    int `json:"active"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+
+	// SucceededIndexes and FailedIndexes record the finished children, a child deleted after it finished
+	// still counts and isn't forged again.
+	// +optional
+	// +listType=set
+	SucceededIndexes []int `json:"succeededIndexes,omitempty"`
+	// +optional
+	// +listType=set
+	FailedIndexes []int `json:"failedIndexes,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the latest observations of the batch's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=eb
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Count",type=integer,JSONPath=`.spec.count`
+// +kubebuilder:printcolumn:name="Active",type=integer,JSONPath=`.status.active`
+// +kubebuilder:printcolumn:name="Success",type=integer,JSONPath=`.status.succeeded`
+// +kubebuilder:printcolumn:name="Fail",type=integer,JSONPath=`.status.failed`
+
+// EnchantmentBatch is the Schema for the enchantmentbatches API
+type EnchantmentBatch struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of EnchantmentBatch
+	// +required
+	Spec EnchantmentBatchSpec `json:"spec"`
+
+	// status defines the observed state of EnchantmentBatch
+	// +optional
+	Status EnchantmentBatchStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnchantmentBatchList contains a list of EnchantmentBatch
+type EnchantmentBatchList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnchantmentBatch `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnchantmentBatch{}, &EnchantmentBatchList{})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConcurrencyPolicy describes how a run is treated while the previous one is still unfinished.
+// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent lets runs overlap.
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+	// ForbidConcurrent skips a run while the previous one is unfinished.
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent deletes the unfinished runs before starting the next one.
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+// ForgeScheduleSpec defines the desired state of ForgeSchedule
+type ForgeScheduleSpec struct {
+	// Schedule in Cron format, see https://en.wikipedia.org/wiki/Cron.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// StartingDeadlineSeconds is how late a missed run may still start, older ones are skipped.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// +optional
+	// +kubebuilder:default=Allow
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// Suspend stops the following runs, the unfinished ones are left alone.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// OrderID is shared by every Enchantment the schedule creates.
+	// +kubebuilder:validation:Minimum=1
+	OrderID int `json:"orderId"`
+
+	// +optional
+	Selector EnchantmentBatchSelector `json:"selector,omitempty"`
+
+	Template EnchantmentBatchTemplate `json:"template"`
+
+	// SuccessfulHistoryLimit is how many Completed Enchantments are kept.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	SuccessfulHistoryLimit *int32 `json:"successfulHistoryLimit,omitempty"`
+
+	// FailedHistoryLimit is how many Failed or Cancelled Enchantments are kept.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	FailedHistoryLimit *int32 `json:"failedHistoryLimit,omitempty"`
+}
+
+// Condition reasons reported on ForgeScheduleStatus.Conditions.
+const (
+	ReasonInvalidSchedule = "InvalidSchedule"
+	ReasonScheduled       = "Scheduled"
+	ReasonRunSkipped      = "RunSkipped"
+	ReasonMissedRuns      = "MissedRuns"
+)
+
+// ForgeScheduleStatus defines the observed state of ForgeSchedule.
+type ForgeScheduleStatus struct {
+	// Active are the unfinished Enchantments of the schedule.
+	// +optional
+	// +listType=atomic
+	Active []corev1.ObjectReference `json:"active,omitempty"`
+
+	// LastScheduleTime is the last time a run was due.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// LastSuccessfulTime is when the last Completed Enchantment of the schedule finished.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// Conditions describe the latest observations of the schedule's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=fs
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ForgeSchedule is the Schema for the forgeschedules API
+type ForgeSchedule struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of ForgeSchedule
+	// +required
+	Spec ForgeScheduleSpec `json:"spec"`
+
+	// status defines the observed state of ForgeSchedule
+	// +optional
+	Status ForgeScheduleStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ForgeScheduleList contains a list of ForgeSchedule
+type ForgeScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ForgeSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ForgeSchedule{}, &ForgeScheduleList{})
+}
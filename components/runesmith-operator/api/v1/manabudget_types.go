@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/fukaraca/runesmith/shared"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManaLimits is an amount of mana per element, an element that is left out isn't limited.
+type ManaLimits struct {
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Fire *int `json:"fire,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Frost *int `json:"frost,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Arcane *int `json:"arcane,omitempty"`
+}
+
+// Limit returns the limit of the element and whether there is one.
+func (l ManaLimits) Limit(energy shared.Elemental) (int, bool) {
+	var limit *int
+	switch energy {
+	case shared.FireEnergy:
+		limit = l.Fire
+	case shared.FrostEnergy:
+		limit = l.Frost
+	case shared.ArcaneEnergy:
+		limit = l.Arcane
+	}
+	if limit == nil {
+		return 0, false
+	}
+	return *limit, true
+}
+
+// ManaBudgetSpec defines the desired state of ManaBudget
+type ManaBudgetSpec struct {
+	// Concurrent caps the mana held at once by the Enchantments of the namespace whose jobs exist, that is
+	// the sum of their requirement limits.
+	// +optional
+	Concurrent ManaLimits `json:"concurrent,omitempty"`
+
+	// DailySpend caps the mana spent by the Enchantments completed in the last 24 hours. A requirement
+	// spends its limit times the cost of the Enchantment.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	DailySpend *int64 `json:"dailySpend,omitempty"`
+}
+
+// ManaUsage is the mana held per element.
+type ManaUsage struct {
+	Fire   int `json:"fire"`
+	Frost  int `json:"frost"`
+	Arcane int `json:"arcane"`
+}
+
+// Add adds the mana of the element.
+func (u *ManaUsage) Add(energy shared.Elemental, mana int) {
+	switch energy {
+	case shared.FireEnergy:
+		u.Fire += mana
+	case shared.FrostEnergy:
+		u.Frost += mana
+	case shared.ArcaneEnergy:
+		u.Arcane += mana
+	}
+}
+
+// Of returns the mana of the element.
+func (u ManaUsage) Of(energy shared.Elemental) int {
+	switch energy {
+	case shared.FireEnergy:
+		return u.Fire
+	case shared.FrostEnergy:
+		return u.Frost
+	case shared.ArcaneEnergy:
+		return u.Arcane
+	}
+	return 0
+}
+
+// ManaSpend is the mana spent by the Enchantments completed within an hour.
+type ManaSpend struct {
+	// Hour is the start of the hour.
+	Hour metav1.Time `json:"hour"`
+	Mana int64       `json:"mana"`
+}
+
+// Condition types and reasons reported on ManaBudgetStatus.Conditions.
+const (
+	// ConditionExhausted is True while the budget holds back new Enchantments.
+	ConditionExhausted = "Exhausted"
+
+	ReasonWithinBudget           = "WithinBudget"
+	ReasonConcurrentLimitReached = "ConcurrentLimitReached"
+	ReasonDailySpendReached      = "DailySpendReached"
+)
+
+// ManaBudgetStatus defines the observed state of ManaBudget.
+type ManaBudgetStatus struct {
+	// Concurrent is the mana held by the Enchantments of the namespace whose jobs exist.
+	// +optional
+	Concurrent ManaUsage `json:"concurrent,omitempty"`
+
+	// SpentToday is the mana spent over the last 24 hours.
+	// +optional
+	SpentToday int64 `json:"spentToday,omitempty"`
+
+	// Spend is the mana spent per hour over the last 24 hours, the operator adds to it as Enchantments
+	// complete.
+	// +optional
+	// +listType=atomic
+	Spend []ManaSpend `json:"spend,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the latest observations of the budget's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mb
+// +kubebuilder:printcolumn:name="Fire",type=integer,JSONPath=`.status.concurrent.fire`
+// +kubebuilder:printcolumn:name="Frost",type=integer,JSONPath=`.status.concurrent.frost`
+// +kubebuilder:printcolumn:name="Arcane",type=integer,JSONPath=`.status.concurrent.arcane`
+// +kubebuilder:printcolumn:name="Spent Today",type=integer,JSONPath=`.status.spentToday`
+// +kubebuilder:printcolumn:name="Daily",type=integer,JSONPath=`.spec.dailySpend`
+
+// ManaBudget is the Schema for the manabudgets API
+type ManaBudget struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of ManaBudget
+	// +required
+	Spec ManaBudgetSpec `json:"spec"`
+
+	// status defines the observed state of ManaBudget
+	// +optional
+	Status ManaBudgetStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManaBudgetList contains a list of ManaBudget
+type ManaBudgetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManaBudget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManaBudget{}, &ManaBudgetList{})
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/fukaraca/runesmith/shared"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecipeRequirements is the mana of each element an item needs, zero means the element isn't used.
+// +kubebuilder:validation:XValidation:rule="self.fire + self.frost + self.arcane > 0",message="at least one element is needed"
+type RecipeRequirements struct {
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=0
+	Fire int `json:"fire,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=0
+	Frost int `json:"frost,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=0
+	Arcane int `json:"arcane,omitempty"`
+}
+
+// RecipeSpec mirrors shared.MagicalItem, the backend serves its catalog from these.
+type RecipeSpec struct {
+	// ID is the catalog id of the item.
+	// +kubebuilder:validation:Minimum=1
+	ID int `json:"id"`
+
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Enum=Common;Rare;Epic;Legendary
+	// +kubebuilder:validation:Type=string
+	Tier shared.Tier `json:"tier"`
+
+	Requirements RecipeRequirements `json:"requirements"`
+
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// Components are the catalog ids of the items forged before this one, the backend expands them
+	// into Enchantments the item depends on.
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	// +kubebuilder:validation:items:Minimum=1
+	Components []int `json:"components,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=rcp
+// +kubebuilder:printcolumn:name="ID",type=integer,JSONPath=`.spec.id`
+// +kubebuilder:printcolumn:name="Item",type=string,JSONPath=`.spec.name`
+// +kubebuilder:printcolumn:name="Tier",type=string,JSONPath=`.spec.tier`
+// +kubebuilder:printcolumn:name="Fire",type=integer,JSONPath=`.spec.requirements.fire`
+// +kubebuilder:printcolumn:name="Frost",type=integer,JSONPath=`.spec.requirements.frost`
+// +kubebuilder:printcolumn:name="Arcane",type=integer,JSONPath=`.spec.requirements.arcane`
+
+// Recipe is the Schema for the recipes API
+type Recipe struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec is the magical item the recipe produces
+	// +required
+	Spec RecipeSpec `json:"spec"`
+}
+
+// MagicalItem converts the recipe to the catalog entry the backend and the enchanter work with.
+func (r *Recipe) MagicalItem() shared.MagicalItem {
+	return shared.MagicalItem{
+		ID:   r.Spec.ID,
+		Name: r.Spec.Name,
+		Tier: r.Spec.Tier,
+		Requirements: shared.Requirements{
+			Fire:   r.Spec.Requirements.Fire,
+			Frost:  r.Spec.Requirements.Frost,
+			Arcane: r.Spec.Requirements.Arcane,
+		},
+		Priority:   r.Spec.Priority,
+		Components: r.Spec.Components,
+	}
+}
+
+// Artifact builds the Enchantment artifact of the recipe with a requirement per element it needs.
+func (r *Recipe) Artifact() EnchantmentSpecArtifact {
+	item := r.MagicalItem()
+	artifact := EnchantmentSpecArtifact{
+		ID:       item.ID,
+		Name:     item.Name,
+		Tier:     item.Tier,
+		Priority: item.Priority,
+	}
+	// fixed order so that the pinned status doesn't depend on map iteration
+	for _, energy := range []shared.Elemental{shared.FireEnergy, shared.FrostEnergy, shared.ArcaneEnergy} {
+		if limit, ok := item.RequiredList()[energy]; ok {
+			artifact.Requirements = append(artifact.Requirements, EnchantmentSpecArtifactRequirement{
+				EnergyType:   energy,
+				ResourceName: energy.Resource(),
+				Limit:        limit,
+			})
+		}
+	}
+	return artifact
+}
+
+// +kubebuilder:object:root=true
+
+// RecipeList contains a list of Recipe
+type RecipeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Recipe `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Recipe{}, &RecipeList{})
+}
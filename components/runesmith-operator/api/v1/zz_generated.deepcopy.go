@@ -21,9 +21,156 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchanterPodOverlay) DeepCopyInto(out *EnchanterPodOverlay) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchanterPodOverlay.
+func (in *EnchanterPodOverlay) DeepCopy() *EnchanterPodOverlay {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchanterPodOverlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchanterProfile) DeepCopyInto(out *EnchanterProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchanterProfile.
+func (in *EnchanterProfile) DeepCopy() *EnchanterProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchanterProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnchanterProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchanterProfileList) DeepCopyInto(out *EnchanterProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EnchanterProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchanterProfileList.
+func (in *EnchanterProfileList) DeepCopy() *EnchanterProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchanterProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnchanterProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchanterProfileSpec) DeepCopyInto(out *EnchanterProfileSpec) {
+	*out = *in
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchanterProfileSpec.
+func (in *EnchanterProfileSpec) DeepCopy() *EnchanterProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchanterProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Enchantment) DeepCopyInto(out *Enchantment) {
 	*out = *in
@@ -51,6 +198,182 @@ func (in *Enchantment) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentBatch) DeepCopyInto(out *EnchantmentBatch) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentBatch.
+func (in *EnchantmentBatch) DeepCopy() *EnchantmentBatch {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentBatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnchantmentBatch) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentBatchList) DeepCopyInto(out *EnchantmentBatchList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EnchantmentBatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentBatchList.
+func (in *EnchantmentBatchList) DeepCopy() *EnchantmentBatchList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentBatchList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnchantmentBatchList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentBatchSelector) DeepCopyInto(out *EnchantmentBatchSelector) {
+	*out = *in
+	if in.ItemID != nil {
+		in, out := &in.ItemID, &out.ItemID
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentBatchSelector.
+func (in *EnchantmentBatchSelector) DeepCopy() *EnchantmentBatchSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentBatchSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentBatchSpec) DeepCopyInto(out *EnchantmentBatchSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentBatchSpec.
+func (in *EnchantmentBatchSpec) DeepCopy() *EnchantmentBatchSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentBatchSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentBatchStatus) DeepCopyInto(out *EnchantmentBatchStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SucceededIndexes != nil {
+		in, out := &in.SucceededIndexes, &out.SucceededIndexes
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedIndexes != nil {
+		in, out := &in.FailedIndexes, &out.FailedIndexes
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentBatchStatus.
+func (in *EnchantmentBatchStatus) DeepCopy() *EnchantmentBatchStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentBatchStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentBatchTemplate) DeepCopyInto(out *EnchantmentBatchTemplate) {
+	*out = *in
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(EnchantmentRetryPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentBatchTemplate.
+func (in *EnchantmentBatchTemplate) DeepCopy() *EnchantmentBatchTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentBatchTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentDependency) DeepCopyInto(out *EnchantmentDependency) {
+	*out = *in
+	if in.ItemID != nil {
+		in, out := &in.ItemID, &out.ItemID
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentDependency.
+func (in *EnchantmentDependency) DeepCopy() *EnchantmentDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnchantmentList) DeepCopyInto(out *EnchantmentList) {
 	*out = *in
@@ -83,6 +406,38 @@ func (in *EnchantmentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentRequirementStatus) DeepCopyInto(out *EnchantmentRequirementStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AdmissionTime != nil {
+		in, out := &in.AdmissionTime, &out.AdmissionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.QueuePosition != nil {
+		in, out := &in.QueuePosition, &out.QueuePosition
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentRequirementStatus.
+func (in *EnchantmentRequirementStatus) DeepCopy() *EnchantmentRequirementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentRequirementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnchantmentRetentionPolicy) DeepCopyInto(out *EnchantmentRetentionPolicy) {
 	*out = *in
@@ -103,16 +458,53 @@ func (in *EnchantmentRetentionPolicy) DeepCopy() *EnchantmentRetentionPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnchantmentRetryPolicy) DeepCopyInto(out *EnchantmentRetryPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentRetryPolicy.
+func (in *EnchantmentRetryPolicy) DeepCopy() *EnchantmentRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EnchantmentRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnchantmentSpec) DeepCopyInto(out *EnchantmentSpec) {
 	*out = *in
 	in.Retention.DeepCopyInto(&out.Retention)
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(EnchantmentRetryPolicy)
+		**out = **in
+	}
 	in.Artifact.DeepCopyInto(&out.Artifact)
 	if in.SelfReport != nil {
 		in, out := &in.SelfReport, &out.SelfReport
 		*out = new(bool)
 		**out = **in
 	}
+	if in.DeadlineSeconds != nil {
+		in, out := &in.DeadlineSeconds, &out.DeadlineSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.QueueTimeoutSeconds != nil {
+		in, out := &in.QueueTimeoutSeconds, &out.QueueTimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]EnchantmentDependency, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentSpec.
@@ -163,6 +555,11 @@ func (in *EnchantmentSpecArtifactRequirement) DeepCopy() *EnchantmentSpecArtifac
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnchantmentStatus) DeepCopyInto(out *EnchantmentStatus) {
 	*out = *in
+	if in.Artifact != nil {
+		in, out := &in.Artifact, &out.Artifact
+		*out = new(EnchantmentSpecArtifact)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CompletionTime != nil {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
@@ -171,6 +568,20 @@ func (in *EnchantmentStatus) DeepCopyInto(out *EnchantmentStatus) {
 		in, out := &in.ExpiresAt, &out.ExpiresAt
 		*out = (*in).DeepCopy()
 	}
+	if in.Requirements != nil {
+		in, out := &in.Requirements, &out.Requirements
+		*out = make([]EnchantmentRequirementStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnchantmentStatus.
@@ -182,3 +593,394 @@ func (in *EnchantmentStatus) DeepCopy() *EnchantmentStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForgeSchedule) DeepCopyInto(out *ForgeSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForgeSchedule.
+func (in *ForgeSchedule) DeepCopy() *ForgeSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(ForgeSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ForgeSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForgeScheduleList) DeepCopyInto(out *ForgeScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ForgeSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForgeScheduleList.
+func (in *ForgeScheduleList) DeepCopy() *ForgeScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(ForgeScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ForgeScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForgeScheduleSpec) DeepCopyInto(out *ForgeScheduleSpec) {
+	*out = *in
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	in.Selector.DeepCopyInto(&out.Selector)
+	in.Template.DeepCopyInto(&out.Template)
+	if in.SuccessfulHistoryLimit != nil {
+		in, out := &in.SuccessfulHistoryLimit, &out.SuccessfulHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedHistoryLimit != nil {
+		in, out := &in.FailedHistoryLimit, &out.FailedHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForgeScheduleSpec.
+func (in *ForgeScheduleSpec) DeepCopy() *ForgeScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ForgeScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForgeScheduleStatus) DeepCopyInto(out *ForgeScheduleStatus) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForgeScheduleStatus.
+func (in *ForgeScheduleStatus) DeepCopy() *ForgeScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ForgeScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaBudget) DeepCopyInto(out *ManaBudget) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaBudget.
+func (in *ManaBudget) DeepCopy() *ManaBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManaBudget) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaBudgetList) DeepCopyInto(out *ManaBudgetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ManaBudget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaBudgetList.
+func (in *ManaBudgetList) DeepCopy() *ManaBudgetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaBudgetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManaBudgetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaBudgetSpec) DeepCopyInto(out *ManaBudgetSpec) {
+	*out = *in
+	in.Concurrent.DeepCopyInto(&out.Concurrent)
+	if in.DailySpend != nil {
+		in, out := &in.DailySpend, &out.DailySpend
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaBudgetSpec.
+func (in *ManaBudgetSpec) DeepCopy() *ManaBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaBudgetStatus) DeepCopyInto(out *ManaBudgetStatus) {
+	*out = *in
+	out.Concurrent = in.Concurrent
+	if in.Spend != nil {
+		in, out := &in.Spend, &out.Spend
+		*out = make([]ManaSpend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaBudgetStatus.
+func (in *ManaBudgetStatus) DeepCopy() *ManaBudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaBudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaLimits) DeepCopyInto(out *ManaLimits) {
+	*out = *in
+	if in.Fire != nil {
+		in, out := &in.Fire, &out.Fire
+		*out = new(int)
+		**out = **in
+	}
+	if in.Frost != nil {
+		in, out := &in.Frost, &out.Frost
+		*out = new(int)
+		**out = **in
+	}
+	if in.Arcane != nil {
+		in, out := &in.Arcane, &out.Arcane
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaLimits.
+func (in *ManaLimits) DeepCopy() *ManaLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaSpend) DeepCopyInto(out *ManaSpend) {
+	*out = *in
+	in.Hour.DeepCopyInto(&out.Hour)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaSpend.
+func (in *ManaSpend) DeepCopy() *ManaSpend {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaSpend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManaUsage) DeepCopyInto(out *ManaUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManaUsage.
+func (in *ManaUsage) DeepCopy() *ManaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ManaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Recipe) DeepCopyInto(out *Recipe) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recipe.
+func (in *Recipe) DeepCopy() *Recipe {
+	if in == nil {
+		return nil
+	}
+	out := new(Recipe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Recipe) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecipeList) DeepCopyInto(out *RecipeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Recipe, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecipeList.
+func (in *RecipeList) DeepCopy() *RecipeList {
+	if in == nil {
+		return nil
+	}
+	out := new(RecipeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RecipeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecipeRequirements) DeepCopyInto(out *RecipeRequirements) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecipeRequirements.
+func (in *RecipeRequirements) DeepCopy() *RecipeRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(RecipeRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecipeSpec) DeepCopyInto(out *RecipeSpec) {
+	*out = *in
+	out.Requirements = in.Requirements
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecipeSpec.
+func (in *RecipeSpec) DeepCopy() *RecipeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecipeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
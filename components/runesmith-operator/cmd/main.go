@@ -21,16 +21,20 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -39,6 +43,8 @@ import (
 
 	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
 	"github.com/fukaraca/runesmith/components/runesmith-operator/internal/controller"
+	"github.com/fukaraca/runesmith/components/runesmith-operator/internal/kueue"
+	webhookenchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -51,12 +57,13 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(enchantmentv1.AddToScheme(scheme))
+	utilruntime.Must(kueue.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
 // nolint:gocyclo
 func main() {
-	var enchanterImage string
+	var enchanterImage, defaultProfile, defaultQueue string
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
@@ -64,8 +71,16 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var missingDependencyTimeout time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&enchanterImage, "enchanter-image", "ghcr.io/fukaraca/runesmith-enchanter:1.0.12", "The image to use for the enchanter job.")
+	flag.StringVar(&defaultProfile, "default-enchanter-profile", "",
+		"The EnchanterProfile used by Enchantments that don't name one. Built-in defaults apply when empty.")
+	flag.StringVar(&defaultQueue, "default-local-queue", "runesmith-queue",
+		"The LocalQueue of the namespaces that don't name one with the enchantment.runesmith.io/local-queue "+
+			"annotation or label.")
+	flag.DurationVar(&missingDependencyTimeout, "missing-dependency-timeout", 10*time.Minute,
+		"How long a Blocked Enchantment waits for a dependency that doesn't exist before it fails.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -181,7 +196,12 @@ func main() {
 	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
+		Scheme: scheme,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Pod{}: {Label: controller.PodCacheSelector()},
+			},
+		},
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -209,13 +229,44 @@ func main() {
 	}
 
 	if err := (&controller.EnchantmentReconciler{
-		Image:  enchanterImage,
+		Image:                    enchanterImage,
+		DefaultProfile:           defaultProfile,
+		DefaultQueue:             defaultQueue,
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		MissingDependencyTimeout: missingDependencyTimeout,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Enchantment")
+		os.Exit(1)
+	}
+	if err := (&controller.EnchantmentBatchReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Enchantment")
+		setupLog.Error(err, "unable to create controller", "controller", "EnchantmentBatch")
+		os.Exit(1)
+	}
+	if err := (&controller.ForgeScheduleReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ForgeSchedule")
 		os.Exit(1)
 	}
+	if err := (&controller.ManaBudgetReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ManaBudget")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookenchantmentv1.SetupEnchantmentWebhookWithManager(mgr, defaultProfile); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Enchantment")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
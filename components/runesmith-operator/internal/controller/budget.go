@@ -0,0 +1,319 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// spendWindow is how far back ManaBudget.Spec.DailySpend looks
+const spendWindow = 24 * time.Hour
+
+// artifactOf is the artifact the jobs of the Enchantment are built from, the pinned recipe wins
+func artifactOf(ench *enchv1.Enchantment) *enchv1.EnchantmentSpecArtifact {
+	if ench.Status.Artifact != nil {
+		return ench.Status.Artifact
+	}
+	return &ench.Spec.Artifact
+}
+
+// holdsMana reports whether the jobs of the Enchantment count against the concurrent budget. Paused jobs
+// are suspended and gave their mana back.
+func holdsMana(ench *enchv1.Enchantment) bool {
+	switch ench.Status.Phase {
+	case shared.PausedAS, shared.BlockedAS, shared.QuotaExceededAS:
+		return false
+	}
+	return !isTerminalPhase(ench.Status.Phase) &&
+		apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchv1.ConditionJobsCreated)
+}
+
+// concurrentUsage sums the requirement limits of the Enchantments holding mana
+func concurrentUsage(list []enchv1.Enchantment) enchv1.ManaUsage {
+	var usage enchv1.ManaUsage
+	for i := range list {
+		if !holdsMana(&list[i]) {
+			continue
+		}
+		for _, req := range artifactOf(&list[i]).Requirements {
+			usage.Add(req.EnergyType, req.Limit)
+		}
+	}
+	return usage
+}
+
+// manaSpend is what completing the Enchantment costs, every requirement spends its limit times the cost
+func manaSpend(ench *enchv1.Enchantment) int64 {
+	var mana int64
+	for _, req := range artifactOf(ench).Requirements {
+		mana += int64(req.Limit) * int64(ench.Spec.Cost)
+	}
+	return mana
+}
+
+// inWindow keeps the hourly spends that still count at now and sums them
+func inWindow(spend []enchv1.ManaSpend, now time.Time) ([]enchv1.ManaSpend, int64) {
+	var kept []enchv1.ManaSpend
+	var total int64
+	for _, s := range spend {
+		if s.Hour.Add(spendWindow).After(now) {
+			kept = append(kept, s)
+			total += s.Mana
+		}
+	}
+	return kept, total
+}
+
+// nextRollover is how long until the oldest hourly spend leaves the window, zero without any
+func nextRollover(spend []enchv1.ManaSpend, now time.Time) time.Duration {
+	var wait time.Duration
+	for _, s := range spend {
+		if d := s.Hour.Add(spendWindow).Sub(now); d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// reservations are the Enchantments admitted against the ManaBudgets of their namespace whose JobsCreated
+// hasn't reached the cache yet, by namespace and name. Without them a burst of admissions overshoots.
+type reservations struct {
+	mu          sync.Mutex
+	byNamespace map[string]map[string]*enchv1.Enchantment
+}
+
+func (r *reservations) reserve(ench *enchv1.Enchantment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.byNamespace == nil {
+		r.byNamespace = make(map[string]map[string]*enchv1.Enchantment)
+	}
+	if r.byNamespace[ench.Namespace] == nil {
+		r.byNamespace[ench.Namespace] = make(map[string]*enchv1.Enchantment)
+	}
+	held := ench.DeepCopy()
+	held.Status.Phase = shared.EnchantingAS
+	apimeta.SetStatusCondition(&held.Status.Conditions, metav1.Condition{
+		Type: enchv1.ConditionJobsCreated, Status: metav1.ConditionTrue, Reason: enchv1.ReasonJobsCreated})
+	r.byNamespace[ench.Namespace][ench.Name] = held
+}
+
+func (r *reservations) release(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byNamespace[key.Namespace], key.Name)
+	if len(r.byNamespace[key.Namespace]) == 0 {
+		delete(r.byNamespace, key.Namespace)
+	}
+}
+
+// admitted returns the reservations of the namespace the cached list doesn't show holding mana yet, except
+// the one of self. The ones the cache caught up with, or that are gone or no longer admitted, are dropped.
+func (r *reservations) admitted(namespace string, list []enchv1.Enchantment, self types.UID) []enchv1.Enchantment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reserved := r.byNamespace[namespace]
+	if len(reserved) == 0 {
+		return nil
+	}
+	cached := make(map[string]*enchv1.Enchantment, len(list))
+	for i := range list {
+		cached[list[i].Name] = &list[i]
+	}
+	var admitted []enchv1.Enchantment
+	for name, held := range reserved {
+		item, ok := cached[name]
+		if !ok || item.UID != held.UID || holdsMana(item) || isTerminalPhase(item.Status.Phase) ||
+			item.Status.Phase == shared.PausedAS {
+			delete(reserved, name)
+			continue
+		}
+		if held.UID != self {
+			admitted = append(admitted, *held)
+		}
+	}
+	if len(reserved) == 0 {
+		delete(r.byNamespace, namespace)
+	}
+	return admitted
+}
+
+// overBudget explains which of the ManaBudgets of its namespace the Enchantment would exceed, empty when
+// it fits all of them. The duration tells when the daily spend frees up, zero when only finishing
+// Enchantments can make room.
+func (r *EnchantmentReconciler) overBudget(ctx context.Context, ench *enchv1.Enchantment,
+	budgets []enchv1.ManaBudget) (string, time.Duration, error) {
+	var list enchv1.EnchantmentList
+	if err := r.List(ctx, &list, client.InNamespace(ench.Namespace)); err != nil {
+		return "", 0, err
+	}
+	others := make([]enchv1.Enchantment, 0, len(list.Items))
+	for _, item := range list.Items {
+		if item.UID != ench.UID {
+			others = append(others, item)
+		}
+	}
+	// admitted ones count until the cache shows them holding mana
+	others = append(others, r.reserved.admitted(ench.Namespace, list.Items, ench.UID)...)
+	usage := concurrentUsage(others)
+	var pending int64 // spend of the running ones, charged once they complete
+	for i := range others {
+		if holdsMana(&others[i]) {
+			pending += manaSpend(&others[i])
+		}
+	}
+
+	now := r.now()
+	var reasons []string
+	var wait time.Duration
+	for _, budget := range budgets {
+		for _, req := range artifactOf(ench).Requirements {
+			limit, ok := budget.Spec.Concurrent.Limit(req.EnergyType)
+			if ok && usage.Of(req.EnergyType)+req.Limit > limit {
+				reasons = append(reasons, fmt.Sprintf("%s: %d of %d %s mana held", budget.Name,
+					usage.Of(req.EnergyType), limit, req.EnergyType))
+			}
+		}
+		if daily := budget.Spec.DailySpend; daily != nil {
+			_, spent := inWindow(budget.Status.Spend, now)
+			if spent+pending+manaSpend(ench) > *daily {
+				reasons = append(reasons, fmt.Sprintf("%s: %d of %d daily mana spent, %d running", budget.Name,
+					spent, *daily, pending))
+				if d := nextRollover(budget.Status.Spend, now); d > 0 && (wait == 0 || d < wait) {
+					wait = d
+				}
+			}
+		}
+	}
+	return strings.Join(reasons, ", "), wait, nil
+}
+
+// enforceBudgets holds the Enchantment in QuotaExceeded while creating its jobs would exceed a ManaBudget
+// of its namespace
+func (r *EnchantmentReconciler) enforceBudgets(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, bool, error) {
+	key := client.ObjectKeyFromObject(ench)
+	var budgets enchv1.ManaBudgetList
+	if err := r.List(ctx, &budgets, client.InNamespace(ench.Namespace)); err != nil {
+		return ctrl.Result{}, false, err
+	}
+	if len(budgets.Items) == 0 {
+		r.reserved.release(key)
+		return ctrl.Result{}, false, nil
+	}
+	msg, wait, err := r.overBudget(ctx, ench, budgets.Items)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+	if msg == "" {
+		r.reserved.reserve(ench)
+		return ctrl.Result{}, false, nil
+	}
+	r.reserved.release(key)
+	if c := apimeta.FindStatusCondition(ench.Status.Conditions, enchv1.ConditionJobsCreated); phase == shared.QuotaExceededAS &&
+		c != nil && c.Reason == enchv1.ReasonQuotaExceeded && c.Message == msg {
+		return ctrl.Result{RequeueAfter: wait}, true, nil
+	}
+
+	ptr.phase = shared.QuotaExceededAS.Ptr()
+	ptr.setCondition(enchv1.ConditionJobsCreated, metav1.ConditionFalse, enchv1.ReasonQuotaExceeded, msg)
+	ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonQuotaExceeded, msg)
+	if err = r.reconcileStatus(ctx, ptr); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update Enchantment status", "from", phase, "to", shared.QuotaExceededAS)
+		return ctrl.Result{RequeueAfter: time.Second}, true, nil
+	}
+	if phase != shared.QuotaExceededAS {
+		r.Recorder.Event(ench, corev1.EventTypeWarning, enchv1.ReasonQuotaExceeded, msg)
+	}
+	// finishing Enchantments and budget changes bring us back, the daily spend needs a timer
+	return ctrl.Result{RequeueAfter: wait}, true, nil
+}
+
+// chargeOnce charges the spend of a completed Enchantment whose BudgetCharged is still False. The status is
+// claimed first with the resourceVersion it was read at, a stale copy conflicts there instead of charging
+// twice. BudgetCharged turns True only once the charge went through, a failed one is retried.
+func (r *EnchantmentReconciler) chargeOnce(ctx context.Context, ench *enchv1.Enchantment) error {
+	mana := manaSpend(ench)
+	key := client.ObjectKeyFromObject(ench)
+	claim := &ptrStatus{namespacedName: key, phase: shared.CompletedAS.Ptr(), since: ench.ResourceVersion}
+	claim.setCondition(enchv1.ConditionBudgetCharged, metav1.ConditionFalse, enchv1.ReasonChargePending,
+		fmt.Sprintf("%d mana to charge", mana))
+	if err := r.reconcileStatus(ctx, claim); err != nil {
+		return err
+	}
+	if err := r.chargeBudgets(ctx, ench); err != nil {
+		return fmt.Errorf("charge ManaBudgets: %w", err)
+	}
+
+	charged := &ptrStatus{namespacedName: key, phase: shared.CompletedAS.Ptr()}
+	charged.setCondition(enchv1.ConditionBudgetCharged, metav1.ConditionTrue, enchv1.ReasonSpendCharged,
+		fmt.Sprintf("%d mana spent", mana))
+	return r.reconcileStatus(ctx, charged)
+}
+
+// chargeBudgets adds the spend of a completed Enchantment to every ManaBudget of its namespace, see
+// chargeOnce.
+func (r *EnchantmentReconciler) chargeBudgets(ctx context.Context, ench *enchv1.Enchantment) error {
+	var budgets enchv1.ManaBudgetList
+	if err := r.List(ctx, &budgets, client.InNamespace(ench.Namespace)); err != nil {
+		return err
+	}
+	mana := manaSpend(ench)
+	if mana == 0 {
+		return nil
+	}
+	now := r.now()
+	hour := metav1.NewTime(now.Truncate(time.Hour))
+	for _, item := range budgets.Items {
+		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			var budget enchv1.ManaBudget
+			if err := r.Get(ctx, client.ObjectKeyFromObject(&item), &budget); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+			spend, _ := inWindow(budget.Status.Spend, now)
+			if n := len(spend); n > 0 && spend[n-1].Hour.Equal(&hour) {
+				spend[n-1].Mana += mana
+			} else {
+				spend = append(spend, enchv1.ManaSpend{Hour: hour, Mana: mana})
+			}
+			budget.Status.Spend = spend
+			_, budget.Status.SpentToday = inWindow(spend, now)
+			return r.Status().Update(ctx, &budget)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enchantmentsOverBudget wakes up the Enchantments of the namespace held back by a ManaBudget, a budget
+// change or a finishing Enchantment may make room for them
+func (r *EnchantmentReconciler) enchantmentsOverBudget(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list enchv1.EnchantmentList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list enchantments over budget", "namespace", obj.GetNamespace())
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, ench := range list.Items {
+		if ench.Status.Phase != shared.QuotaExceededAS {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ench)})
+	}
+	return reqs
+}
@@ -0,0 +1,12 @@
+package controller
+
+import "time"
+
+// Clock tells the current time, tests swap it for a fixed one
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
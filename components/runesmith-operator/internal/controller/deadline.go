@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// isQueuedPhase reports the phases spec.queueTimeoutSeconds counts against
+func isQueuedPhase(phase shared.EnchantmentPhase) bool {
+	switch phase {
+	case shared.ScheduledAS, shared.QuotaExceededAS, shared.WaitingForGangAS, shared.RequeuedAS, shared.PreemptedAS:
+		return true
+	}
+	return false
+}
+
+// queuedSince is when the Enchantment last started waiting for admission. The Admitted condition keeps its
+// transition time while it stays False, so Requeued after Preempted doesn't restart the clock. Resuming
+// restarts it, Paused doesn't count.
+func queuedSince(ench *enchv1.Enchantment) time.Time {
+	if c := apimeta.FindStatusCondition(ench.Status.Conditions, enchv1.ConditionAdmitted); c != nil && c.Status == metav1.ConditionFalse {
+		return c.LastTransitionTime.Time
+	}
+	return ench.CreationTimestamp.Time
+}
+
+// nextDeadline returns the closest deadline that applies in the given phase and the reason to fail with
+// once it passes
+func nextDeadline(ench *enchv1.Enchantment, phase shared.EnchantmentPhase) (time.Time, string, bool) {
+	var at time.Time
+	var reason string
+	if isTerminalPhase(phase) {
+		return at, "", false
+	}
+	if ench.Spec.DeadlineSeconds != nil {
+		at = ench.CreationTimestamp.Add(time.Duration(*ench.Spec.DeadlineSeconds) * time.Second)
+		reason = enchv1.ReasonDeadlineExceeded
+	}
+	if ench.Spec.QueueTimeoutSeconds != nil && isQueuedPhase(phase) {
+		queued := queuedSince(ench).Add(time.Duration(*ench.Spec.QueueTimeoutSeconds) * time.Second)
+		if reason == "" || queued.Before(at) {
+			at, reason = queued, enchv1.ReasonQueueTimeout
+		}
+	}
+	return at, reason, reason != ""
+}
+
+// enforceDeadlines fails the Enchantment once one of its deadlines passed. The unfinished jobs are
+// suspended and their Workloads deactivated so that kueue takes their quota back and doesn't readmit them,
+// they stay around for their logs until the ttl.
+func (r *EnchantmentReconciler) enforceDeadlines(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, bool, error) {
+	logger := log.FromContext(ctx)
+	at, reason, ok := nextDeadline(ench, phase)
+	if !ok || r.now().Before(at) {
+		return ctrl.Result{}, false, nil
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs,
+		client.InNamespace(ench.Namespace),
+		client.MatchingFields{jobOwnerIndex: string(ench.UID)},
+	); err != nil {
+		return ctrl.Result{}, false, err
+	}
+	if err := r.setWorkloadsActive(ctx, ench.Namespace, jobs.Items, false); err != nil {
+		return ctrl.Result{}, false, err
+	}
+	for i := range jobs.Items {
+		if _, err := r.suspendJob(ctx, &jobs.Items[i]); err != nil {
+			logger.Error(err, "failed to suspend job", "job", jobs.Items[i].Name)
+			return ctrl.Result{}, false, err
+		}
+	}
+
+	msg := fmt.Sprintf("deadline passed at %s in phase %s", at.UTC().Format(time.RFC3339), phase)
+	if reason == enchv1.ReasonQueueTimeout {
+		msg = fmt.Sprintf("waited for admission longer than %ds", *ench.Spec.QueueTimeoutSeconds)
+	}
+	ptr.phase = shared.FailedAS.Ptr()
+	ptr.markFailed(reason, msg)
+	markCompletion(ench, ptr, r.now())
+	if err := r.reconcileStatus(ctx, ptr); err != nil {
+		logger.Error(err, "failed to update Enchantment status", "from", phase, "to", shared.FailedAS)
+		return ctrl.Result{RequeueAfter: time.Second}, true, nil
+	}
+	observeFinished(ench, shared.FailedAS, r.now())
+	r.Recorder.Event(ench, corev1.EventTypeWarning, reason, msg)
+	logger.Info("enchantment timed out", "name", ench.Name, "reason", reason)
+	if ptr.expiresAt != nil {
+		return ctrl.Result{RequeueAfter: ptr.expiresAt.Sub(r.now())}, true, nil
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// suspendJob suspends a job that is still running or queued, kueue then releases its quota and the pods stop
+func (r *EnchantmentReconciler) suspendJob(ctx context.Context, job *batchv1.Job) (bool, error) {
+	if !job.DeletionTimestamp.IsZero() || isJobFinished(job) || (job.Spec.Suspend != nil && *job.Spec.Suspend) {
+		return false, nil
+	}
+	suspend := true
+	patch := client.MergeFrom(job.DeepCopy())
+	job.Spec.Suspend = &suspend
+	if err := r.Patch(ctx, job, patch); client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,376 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// itemIndex keys an Enchantment by the order and catalog item it forges
+	itemIndex = "itemIndex"
+	// dependsOnIndex keys an Enchantment by every dependency it waits for
+	dependsOnIndex = "dependsOnIndex"
+
+	// defaultMissingDependencyTimeout is how long an Enchantment waits for a dependency nobody created
+	defaultMissingDependencyTimeout = 10 * time.Minute
+)
+
+// dependencyState is how far a single spec.dependsOn entry got
+type dependencyState int
+
+const (
+	dependencyPending dependencyState = iota
+	// dependencyMissing is pending on an Enchantment nobody created yet, it may still show up
+	dependencyMissing
+	dependencyFailed
+	dependencyCompleted
+)
+
+// itemKey is the itemIndex value of an Enchantment
+func itemKey(orderID, itemID int) string {
+	return fmt.Sprintf("%d/%d", orderID, itemID)
+}
+
+// enchantmentItemID is the catalog item the Enchantment forges, the pinned artifact wins over the spec
+func enchantmentItemID(ench *enchv1.Enchantment) int {
+	if ench.Status.Artifact != nil {
+		return ench.Status.Artifact.ID
+	}
+	return ench.Spec.Artifact.ID
+}
+
+// dependencyKey is the dependsOnIndex value of a single spec.dependsOn entry
+func dependencyKey(orderID int, dep enchv1.EnchantmentDependency) string {
+	if dep.ItemID != nil {
+		return "item:" + itemKey(orderID, *dep.ItemID)
+	}
+	return "name:" + dep.Name
+}
+
+// waitingForDependencies reports whether the Enchantment has dependencies that didn't complete yet
+func waitingForDependencies(ench *enchv1.Enchantment) bool {
+	return len(ench.Spec.DependsOn) > 0 &&
+		!apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchv1.ConditionDependenciesMet)
+}
+
+// resolveDependency lists the Enchantments a single spec.dependsOn entry refers to, the Enchantment itself
+// included when it forges the item
+func (r *EnchantmentReconciler) resolveDependency(ctx context.Context, ench *enchv1.Enchantment,
+	dep enchv1.EnchantmentDependency) ([]enchv1.Enchantment, error) {
+	if dep.ItemID != nil {
+		var list enchv1.EnchantmentList
+		if err := r.List(ctx, &list,
+			client.InNamespace(ench.Namespace),
+			client.MatchingFields{itemIndex: itemKey(ench.Spec.OrderID, *dep.ItemID)},
+		); err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	var item enchv1.Enchantment
+	err := r.Get(ctx, client.ObjectKey{Namespace: ench.Namespace, Name: dep.Name}, &item)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []enchv1.Enchantment{item}, nil
+}
+
+// checkDependency summarizes the Enchantments a single spec.dependsOn entry refers to
+func (r *EnchantmentReconciler) checkDependency(ctx context.Context, ench *enchv1.Enchantment,
+	dep enchv1.EnchantmentDependency) (dependencyState, string, error) {
+	items, err := r.resolveDependency(ctx, ench, dep)
+	if err != nil {
+		return dependencyPending, "", err
+	}
+	deps := make([]enchv1.Enchantment, 0, len(items))
+	for _, item := range items {
+		if item.UID != ench.UID {
+			deps = append(deps, item)
+		}
+	}
+	if len(deps) == 0 {
+		if dep.ItemID != nil {
+			return dependencyMissing, fmt.Sprintf("item %d of order %d not found", *dep.ItemID, ench.Spec.OrderID), nil
+		}
+		return dependencyMissing, fmt.Sprintf("Enchantment %q not found", dep.Name), nil
+	}
+
+	for _, item := range deps {
+		switch item.Status.Phase {
+		case shared.FailedAS, shared.CancelledAS, shared.DeletedAS:
+			return dependencyFailed, fmt.Sprintf("dependency %s ended as %s", item.Name, item.Status.Phase), nil
+		}
+	}
+	for _, item := range deps {
+		if item.Status.Phase != shared.CompletedAS {
+			return dependencyPending, fmt.Sprintf("dependency %s is %s", item.Name, item.Status.Phase), nil
+		}
+	}
+	return dependencyCompleted, "", nil
+}
+
+// dependencyCycle returns the Enchantment through which following spec.dependsOn leads back to this one.
+// Only Enchantments still waiting for their dependencies are followed, the others can't be on a cycle.
+func (r *EnchantmentReconciler) dependencyCycle(ctx context.Context, ench *enchv1.Enchantment) (string, error) {
+	visited := map[types.UID]bool{ench.UID: true}
+	queue := []enchv1.Enchantment{*ench}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range current.Spec.DependsOn {
+			items, err := r.resolveDependency(ctx, &current, dep)
+			if err != nil {
+				return "", err
+			}
+			for _, item := range items {
+				if item.UID == current.UID {
+					continue
+				}
+				if item.UID == ench.UID {
+					return current.Name, nil
+				}
+				if visited[item.UID] || isTerminalPhase(item.Status.Phase) || !waitingForDependencies(&item) {
+					continue
+				}
+				visited[item.UID] = true
+				queue = append(queue, item)
+			}
+		}
+	}
+	return "", nil
+}
+
+// missingDependencyTimeout is how long a dependency may be missing before the Enchantment fails
+func (r *EnchantmentReconciler) missingDependencyTimeout() time.Duration {
+	if r.MissingDependencyTimeout <= 0 {
+		return defaultMissingDependencyTimeout
+	}
+	return r.MissingDependencyTimeout
+}
+
+// waitForDependencies keeps the Enchantment Blocked until every dependency completed and fails it once
+// any of them can no longer complete: it failed, it leads back to the Enchantment, or it is still missing
+// missingDependencyTimeout after the Enchantment got Blocked. Dependencies are checked only until they are
+// met, a dependency removed later doesn't affect the Enchantment anymore.
+func (r *EnchantmentReconciler) waitForDependencies(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	var pending, missing []string
+	for _, dep := range ench.Spec.DependsOn {
+		state, msg, err := r.checkDependency(ctx, ench, dep)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		switch state {
+		case dependencyFailed:
+			return r.failDependent(ctx, ench, phase, ptr, enchv1.ReasonDependencyFailed, msg)
+		case dependencyMissing:
+			missing = append(missing, msg)
+			pending = append(pending, msg)
+		case dependencyPending:
+			pending = append(pending, msg)
+		}
+	}
+
+	if len(pending) > 0 {
+		via, err := r.dependencyCycle(ctx, ench)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if via != "" {
+			return r.failDependent(ctx, ench, phase, ptr, enchv1.ReasonDependencyCycle,
+				fmt.Sprintf("dependencies lead back to the enchantment through %s", via))
+		}
+
+		// the dependencies' phase changes bring us back, only a missing dependency needs the clock
+		var result ctrl.Result
+		if len(missing) > 0 {
+			blockedSince := r.now()
+			if c := apimeta.FindStatusCondition(ench.Status.Conditions, enchv1.ConditionDependenciesMet); c != nil && c.Status == metav1.ConditionFalse {
+				blockedSince = c.LastTransitionTime.Time
+			}
+			timeout := r.missingDependencyTimeout()
+			result.RequeueAfter = blockedSince.Add(timeout).Sub(r.now())
+			if result.RequeueAfter <= 0 {
+				return r.failDependent(ctx, ench, phase, ptr, enchv1.ReasonDependencyFailed,
+					fmt.Sprintf("%s after %s", strings.Join(missing, ", "), timeout))
+			}
+		}
+
+		msg := strings.Join(pending, ", ")
+		if phase == shared.BlockedAS && apimeta.IsStatusConditionFalse(ench.Status.Conditions, enchv1.ConditionDependenciesMet) {
+			if c := apimeta.FindStatusCondition(ench.Status.Conditions, enchv1.ConditionDependenciesMet); c.Message == msg {
+				return result, nil
+			}
+		}
+		ptr.phase = shared.BlockedAS.Ptr()
+		ptr.setCondition(enchv1.ConditionDependenciesMet, metav1.ConditionFalse, enchv1.ReasonDependenciesPending, msg)
+		ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonDependenciesPending, msg)
+		if err := r.reconcileStatus(ctx, ptr); err != nil {
+			logger.Error(err, "failed to update Enchantment status", "from", phase, "to", shared.BlockedAS)
+			return ctrl.Result{RequeueAfter: time.Second}, nil
+		}
+		if phase != shared.BlockedAS {
+			r.Recorder.Eventf(ench, corev1.EventTypeNormal, "EnchantmentBlocked", "waiting for %d dependencies", len(pending))
+		}
+		return result, nil
+	}
+
+	// the queue timeout counts from here on, time spent Blocked isn't time spent waiting for admission
+	ptr.phase = shared.ScheduledAS.Ptr()
+	ptr.setCondition(enchv1.ConditionDependenciesMet, metav1.ConditionTrue, enchv1.ReasonDependenciesMet, "all dependencies completed")
+	ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonWaitingForAdmission, "waiting for jobs")
+	ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonWaitingForAdmission, "waiting for jobs")
+	if err := r.reconcileStatus(ctx, ptr); err != nil {
+		logger.Error(err, "failed to update Enchantment status", "from", phase, "to", shared.ScheduledAS)
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	r.Recorder.Event(ench, corev1.EventTypeNormal, enchv1.ReasonDependenciesMet, "all dependencies completed")
+	// the status update brings us back to create the jobs
+	return ctrl.Result{}, nil
+}
+
+// failDependent ends a Blocked Enchantment whose dependencies can't complete anymore
+func (r *EnchantmentReconciler) failDependent(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus, reason, msg string) (ctrl.Result, error) {
+	ptr.phase = shared.FailedAS.Ptr()
+	ptr.markFailed(reason, msg)
+	ptr.setCondition(enchv1.ConditionDependenciesMet, metav1.ConditionFalse, reason, msg)
+	markCompletion(ench, ptr, r.now())
+	if err := r.reconcileStatus(ctx, ptr); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update Enchantment status", "from", phase, "to", shared.FailedAS)
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	observeFinished(ench, shared.FailedAS, r.now())
+	r.Recorder.Event(ench, corev1.EventTypeWarning, reason, msg)
+	if ptr.expiresAt != nil {
+		return ctrl.Result{RequeueAfter: ptr.expiresAt.Sub(r.now())}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// hasBlockedDependents reports whether an Enchantment still waits for this one, the ttl doesn't remove
+// a dependency before its dependents saw how it ended
+func (r *EnchantmentReconciler) hasBlockedDependents(ctx context.Context, ench *enchv1.Enchantment) (bool, error) {
+	dependents, err := r.dependents(ctx, ench)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range dependents {
+		if !isTerminalPhase(d.Status.Phase) && waitingForDependencies(&d) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dependents lists the Enchantments that name this one or the item it forges in spec.dependsOn
+func (r *EnchantmentReconciler) dependents(ctx context.Context, ench *enchv1.Enchantment) ([]enchv1.Enchantment, error) {
+	keys := []string{"name:" + ench.Name}
+	if id := enchantmentItemID(ench); id != 0 {
+		keys = append(keys, "item:"+itemKey(ench.Spec.OrderID, id))
+	}
+	var dependents []enchv1.Enchantment
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		var list enchv1.EnchantmentList
+		if err := r.List(ctx, &list,
+			client.InNamespace(ench.Namespace),
+			client.MatchingFields{dependsOnIndex: key},
+		); err != nil {
+			return nil, err
+		}
+		for _, d := range list.Items {
+			if d.UID == ench.UID || seen[d.Name] {
+				continue
+			}
+			seen[d.Name] = true
+			dependents = append(dependents, d)
+		}
+	}
+	return dependents, nil
+}
+
+// enchantmentsForDependency wakes up the Enchantments blocked on the one whose phase changed
+func (r *EnchantmentReconciler) enchantmentsForDependency(ctx context.Context, obj client.Object) []reconcile.Request {
+	ench, ok := obj.(*enchv1.Enchantment)
+	if !ok {
+		return nil
+	}
+	dependents, err := r.dependents(ctx, ench)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to list dependents", "enchantment", ench.Name)
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, d := range dependents {
+		if !waitingForDependencies(&d) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&d)})
+	}
+	return reqs
+}
+
+// phaseChangedPredicate passes the Enchantment events a dependent can react to
+func phaseChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldEnch, ok := e.ObjectOld.(*enchv1.Enchantment)
+			if !ok {
+				return true
+			}
+			newEnch, ok := e.ObjectNew.(*enchv1.Enchantment)
+			if !ok {
+				return true
+			}
+			return oldEnch.Status.Phase != newEnch.Status.Phase ||
+				enchantmentItemID(oldEnch) != enchantmentItemID(newEnch)
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// setupDependencyIndexes registers the indexes waitForDependencies and the dependency watch list by
+func setupDependencyIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx,
+		&enchv1.Enchantment{}, itemIndex,
+		func(obj client.Object) []string {
+			ench := obj.(*enchv1.Enchantment)
+			if id := enchantmentItemID(ench); id != 0 {
+				return []string{itemKey(ench.Spec.OrderID, id)}
+			}
+			return nil
+		}); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx,
+		&enchv1.Enchantment{}, dependsOnIndex,
+		func(obj client.Object) []string {
+			ench := obj.(*enchv1.Enchantment)
+			keys := make([]string, 0, len(ench.Spec.DependsOn))
+			for _, dep := range ench.Spec.DependsOn {
+				keys = append(keys, dependencyKey(ench.Spec.OrderID, dep))
+			}
+			return keys
+		})
+}
@@ -25,19 +25,27 @@ import (
 	"github.com/fukaraca/runesmith/shared"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/components/runesmith-operator/internal/kueue"
 )
 
 const (
@@ -45,6 +53,9 @@ const (
 	lblKeyWorkload = "workload-type"
 	jobOwnerIndex  = "enchantmentIndex"
 	localKueue     = "runesmith-queue"
+	annKeyAttempt  = "enchantment.runesmith.io/attempt"
+
+	enchantmentFinalizer = "enchantment.runesmith.io/finalizer"
 )
 
 // EnchantmentReconciler reconciles a Enchantment object
@@ -53,19 +64,43 @@ type EnchantmentReconciler struct {
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
 	Image    string
+	// DefaultProfile is the EnchanterProfile used by Enchantments that don't name one
+	DefaultProfile string
+	// DefaultQueue is the LocalQueue of the namespaces that don't name one, runesmith-queue when empty
+	DefaultQueue string
+	// Clock stamps completions and expires them, defaults to the wall clock
+	Clock Clock
+	// MissingDependencyTimeout is how long a Blocked Enchantment waits for a dependency nobody created,
+	// 10 minutes when zero
+	MissingDependencyTimeout time.Duration
+
+	// kueueEnabled is set when the Workload API is served, Workloads are only read then
+	kueueEnabled bool
+	// reserved are the Enchantments admitted against a ManaBudget the cache doesn't show holding mana yet
+	reserved reservations
 }
 
 // +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantments/finalizers,verbs=update
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
-
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Enchantment object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchanterprofiles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=recipes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=manabudgets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=manabudgets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=kueue.x-k8s.io,resources=localqueues,verbs=get;list;watch
+
+// Reconcile drives an Enchantment through its phases with one enchanter Job per artifact requirement.
+// A deleted Enchantment gives back its budget reservation, one being deleted is finalized, otherwise the
+// finalizer is added first. Cancel, the queue timeout and the deadline end the Enchantment before anything
+// else is looked at. A live one then pins its Recipe, is paused or resumed, or waits for its dependencies,
+// and only then does reconcilePhase check the ManaBudgets, create the missing Jobs and derive the phase
+// from the Jobs and their kueue Workloads. Completed Enchantments are charged, finished ones are deleted
+// once their TTL passes unless a dependent still waits for them. Job and Workload events wake it up,
+// RequeueAfter is only set for the next deadline, the TTL and waits no event ends, like a missing dependency.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
@@ -78,22 +113,71 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("enchantment resource not found. ignoring since object must be deleted")
+			jobStates.forget(req.NamespacedName)
+			r.reserved.release(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "failed to get Enchantment")
 		return ctrl.Result{}, err
 	}
+	ptr.generation = ench.Generation
 	if !ench.DeletionTimestamp.IsZero() {
-		return ctrl.Result{}, nil
+		return r.finalize(ctx, ench, ptr)
+	}
+	if !controllerutil.ContainsFinalizer(ench, enchantmentFinalizer) {
+		patch := client.MergeFrom(ench.DeepCopy())
+		controllerutil.AddFinalizer(ench, enchantmentFinalizer)
+		if err = r.Patch(ctx, ench, patch); err != nil {
+			logger.Error(err, "failed to add finalizer")
+			return ctrl.Result{}, err
+		}
 	}
 	phase := ench.Status.Phase
 	if phase == "" {
 		phase = shared.ScheduledAS
 	}
 
+	if ench.Spec.Cancel && !isTerminalPhase(phase) {
+		return r.cancel(ctx, ench, phase, ptr)
+	}
+	if res, expired, err := r.enforceDeadlines(ctx, ench, phase, ptr); expired || err != nil {
+		return res, err
+	}
+	// jobs, metrics and progress are built from the pinned recipe
+	if ench.Status.Artifact != nil {
+		ench.Spec.Artifact = *ench.Status.Artifact
+	}
+	var res ctrl.Result
+	switch {
+	case ench.Spec.Recipe != "" && ench.Status.Artifact == nil && !isTerminalPhase(phase):
+		res, err = r.pinRecipe(ctx, ench, phase, ptr)
+	case ench.Spec.Suspend && !isTerminalPhase(phase):
+		res, err = r.pause(ctx, ench, phase, ptr)
+	case phase == shared.PausedAS:
+		res, err = r.resume(ctx, ench, ptr)
+	case waitingForDependencies(ench) && !isTerminalPhase(phase):
+		res, err = r.waitForDependencies(ctx, ench, phase, ptr)
+	default:
+		res, err = r.reconcilePhase(ctx, ench, phase, ptr)
+	}
+	// wake up in time for the closest deadline even if no job event comes
+	if at, _, ok := nextDeadline(ench, phase); ok {
+		if wait := at.Sub(r.now()); res.RequeueAfter == 0 || wait < res.RequeueAfter {
+			res.RequeueAfter = max(wait, time.Second)
+		}
+	}
+	return res, err
+}
+
+// reconcilePhase moves the Enchantment along from its current phase
+func (r *EnchantmentReconciler) reconcilePhase(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	var err error
+
 	switch phase {
-	case shared.ScheduledAS:
-		// check jobs doesn't exist
+	case shared.ScheduledAS, shared.WaitingForGangAS, shared.QuotaExceededAS:
+		// create whatever requirement is still missing its job, an interrupted reconcile leaves a partial set
 		var jobs batchv1.JobList
 		if err = r.List(ctx, &jobs,
 			client.InNamespace(ench.Namespace),
@@ -101,21 +185,38 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		); err != nil {
 			return ctrl.Result{}, err
 		}
-		if len(jobs.Items) > 0 {
+		existing := latestJobs(jobs.Items)
+		if len(existing) >= len(ench.Spec.Artifact.Requirements) {
 			if !isJobEnchanting(&jobs) {
-				return ctrl.Result{}, nil
+				// still queued, keep admission details and queue positions current
+				return r.reconcileQueued(ctx, ench, existing, ptr)
+			}
+			if ench.Spec.GangScheduling {
+				if err = r.releaseGang(ctx, existing); err != nil {
+					return ctrl.Result{}, err
+				}
+				r.Recorder.Eventf(ench, corev1.EventTypeNormal, "GangAdmitted", "all %d jobs admitted, releasing their pods", len(existing))
 			}
 			ptr.phase = shared.EnchantingAS.Ptr()
+			ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionTrue, enchv1.ReasonAdmitted, "all jobs are admitted")
+			ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionTrue, enchv1.ReasonJobsRunning, "jobs are enchanting")
+			ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonJobsRunning, "jobs are enchanting")
 			if err = r.reconcileStatus(ctx, ptr); err != nil {
 				logger.Error(err, "failed to update Enchantment status", "from", shared.ScheduledAS, "to", shared.EnchantingAS)
 				return ctrl.Result{RequeueAfter: time.Second}, nil
 			}
+			observeAdmitted(ench, r.now())
 			return ctrl.Result{}, nil
 		}
 
-		// create jobs
-		return r.createJobs(ctx, ench, ptr)
-	case shared.EnchantingAS, shared.RequeuedAS:
+		if len(existing) == 0 {
+			// a partial set of jobs already passed the budget check
+			if res, held, err := r.enforceBudgets(ctx, ench, phase, ptr); held || err != nil {
+				return res, err
+			}
+		}
+		return r.createJobs(ctx, ench, existing, ptr)
+	case shared.EnchantingAS, shared.RequeuedAS, shared.PreemptedAS:
 		// list jobs
 		var jobs batchv1.JobList
 		if err = r.List(ctx, &jobs,
@@ -126,24 +227,85 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 
 		if len(jobs.Items) == 0 {
-			logger.Error(err, "unexpected items.len")
+			logger.Info("owned jobs vanished", "name", ench.Name, "from", phase)
 			ptr.phase = shared.FailedAS.Ptr()
-			markCompletion(ench, ptr)
+			ptr.markFailed(enchv1.ReasonJobsVanished, "owned jobs no longer exist")
+			markCompletion(ench, ptr, r.now())
 			if statusErr := r.reconcileStatus(ctx, ptr); statusErr != nil {
 				logger.Error(statusErr, "failed to update Enchantment status", "from", ench.Status.Phase, "to", shared.FailedAS)
 				return ctrl.Result{RequeueAfter: time.Second}, nil
 			}
-			return ctrl.Result{}, fmt.Errorf("unexpected items.len")
+			observeFinished(ench, shared.FailedAS, r.now())
+			r.Recorder.Event(ench, corev1.EventTypeWarning, enchv1.ReasonJobsVanished, "owned jobs no longer exist")
+			if ptr.expiresAt != nil {
+				return ctrl.Result{RequeueAfter: ptr.expiresAt.Sub(r.now())}, nil
+			}
+			return ctrl.Result{}, nil
 		}
 
-		var completedCount, failedCount, activeCount, suspendedCount int
+		view, err := r.listWorkloads(ctx, ench.Namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		var completedCount, failedCount, activeCount, suspendedCount, preemptedCount, retryingCount int
+		var nextRetry time.Duration
+		latest := latestJobs(jobs.Items)
+		requirements := make([]enchv1.EnchantmentRequirementStatus, 0, len(latest))
+
+		for _, ess := range ench.Spec.Artifact.Requirements {
+			job, ok := latest[ess.EnergyType]
+			if !ok {
+				continue
+			}
+			rs, rErr := r.requirementStatus(ctx, job, view)
+			if rErr != nil {
+				return ctrl.Result{}, rErr
+			}
 
-		for _, job := range jobs.Items {
 			if job.Status.Failed > 0 {
-				r.Recorder.Eventf(ench, corev1.EventTypeWarning, "JobFailed", "Job %s failed", job.Name)
-				failedCount++
+				wait, retry := retryAfter(ench.Spec.RetryPolicy, job, r.now())
+				switch {
+				case !retry:
+					r.Recorder.Eventf(ench, corev1.EventTypeWarning, "JobFailed", "Job %s failed", job.Name)
+					failedCount++
+				case wait > 0:
+					rs.State = enchv1.RequirementRetrying
+					retryingCount++
+					if nextRetry == 0 || wait < nextRetry {
+						nextRetry = wait
+					}
+				default:
+					var retried *batchv1.Job
+					profile, cErr := r.resolveProfile(ctx, ench)
+					if cErr == nil {
+						retried, cErr = r.newJob(ench, &ess, profile, rs.Attempts+1)
+					}
+					if cErr == nil {
+						if cErr = r.Create(ctx, retried); errors.IsAlreadyExists(cErr) {
+							cErr = nil
+						}
+					}
+					if cErr != nil {
+						jobCreateFailures.WithLabelValues(ess.EnergyType.String()).Inc()
+						r.Recorder.Eventf(ench, corev1.EventTypeWarning, "JobCreateFailed", "Error: %v", cErr)
+						logger.Error(cErr, "Failed to recreate Job", "failed job", job.Name)
+						return ctrl.Result{}, cErr
+					}
+					r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobRetried", "Job %s failed, retrying as %s (attempt %d/%d)",
+						job.Name, retried.Name, rs.Attempts+1, ench.Spec.RetryPolicy.MaxRetries+1)
+					rs = enchv1.EnchantmentRequirementStatus{
+						EnergyType: ess.EnergyType,
+						JobName:    retried.Name,
+						State:      enchv1.RequirementSuspended,
+						Attempts:   rs.Attempts + 1,
+					}
+					suspendedCount++
+				}
+				requirements = append(requirements, rs)
 				continue
 			}
+			requirements = append(requirements, rs)
 
 			if job.Status.Succeeded > 0 && job.Status.Active == 0 {
 				r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobSucceeded", "Job %s succeeded", job.Name)
@@ -152,7 +314,12 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 
 			if job.Spec.Suspend != nil && *job.Spec.Suspend {
-				r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobSuspended", "Job %s suspended", job.Name)
+				if rs.State == enchv1.RequirementPreempted {
+					r.Recorder.Eventf(ench, corev1.EventTypeWarning, "JobPreempted", "Job %s preempted by kueue", job.Name)
+					preemptedCount++
+				} else {
+					r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobSuspended", "Job %s suspended", job.Name)
+				}
 				suspendedCount++
 				continue
 			}
@@ -162,11 +329,19 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 
+		// pods of a gang only start once none of its jobs is suspended, a recreated pod is gated again
+		if ench.Spec.GangScheduling && suspendedCount == 0 {
+			if err = r.releaseGang(ctx, latest); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
 		progress := fmt.Sprintf("%d/%d", completedCount, len(ench.Spec.Artifact.Requirements))
 		ptr.successful = &completedCount
 		ptr.failed = &failedCount
 		ptr.active = &activeCount
 		ptr.progress = &progress
+		ptr.requirements = requirements
 		var state shared.EnchantmentPhase
 
 		switch {
@@ -174,43 +349,100 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// any job failed means enchantment failed, maybe we can work on detailed reconicle
 			state = shared.FailedAS
 			logger.Info("enchantment failed", "name", ench.Name, "failed jobs", failedCount)
-			markCompletion(ench, ptr)
-		case completedCount == len(jobs.Items):
-			// all jobs completed successfully
+			ptr.markFailed(enchv1.ReasonJobFailed, fmt.Sprintf("%d job(s) failed", failedCount))
+			markCompletion(ench, ptr, r.now())
+		case completedCount == len(ench.Spec.Artifact.Requirements):
+			// every requirement has a succeeded job, a missing job isn't a completed one
 			state = shared.CompletedAS
 			logger.Info("enchantment completed", "name", ench.Name)
-			markCompletion(ench, ptr)
+			ptr.setCondition(enchv1.ConditionSucceeded, metav1.ConditionTrue, enchv1.ReasonJobsSucceeded, "all jobs succeeded")
+			ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonJobsSucceeded, "all jobs succeeded")
+			ptr.setCondition(enchv1.ConditionReady, metav1.ConditionTrue, enchv1.ReasonJobsSucceeded, "all jobs succeeded")
+			// charged by the next reconcile, the condition tells it this one hasn't been yet
+			ptr.setCondition(enchv1.ConditionBudgetCharged, metav1.ConditionFalse, enchv1.ReasonChargePending,
+				fmt.Sprintf("%d mana to charge", manaSpend(ench)))
+			markCompletion(ench, ptr, r.now())
+		case preemptedCount > 0:
+			// kueue evicted jobs for higher priority work, they come back once quota frees up
+			state = shared.PreemptedAS
+			logger.Info("enchantment preempted", "name", ench.Name)
+			msg := fmt.Sprintf("%d job(s) preempted", preemptedCount)
+			ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonPreempted, msg)
+			ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonPreempted, msg)
+			ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonPreempted, msg)
 		case suspendedCount > 0:
 			// any job suspended/requeued means enchantment is requeued
 			state = shared.RequeuedAS
 			logger.Info("enchantment requeued", "name", ench.Name)
+			msg := fmt.Sprintf("%d job(s) suspended", suspendedCount)
+			ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonRequeued, msg)
+			ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonRequeued, msg)
+			ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonRequeued, msg)
 		default:
-			if phase == shared.RequeuedAS {
+			if phase == shared.RequeuedAS || phase == shared.PreemptedAS {
 				r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobResumed", "A pendingJob resumed but we don't know which one")
 			}
 			state = shared.EnchantingAS
+			reason, msg := enchv1.ReasonJobsRunning, progress+" jobs succeeded"
+			if retryingCount > 0 {
+				reason, msg = enchv1.ReasonRetrying, fmt.Sprintf("%d job(s) waiting to be retried", retryingCount)
+			}
+			ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionTrue, enchv1.ReasonAdmitted, "all jobs are admitted")
+			ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionTrue, reason, msg)
+			ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, reason, msg)
 		}
 
 		ptr.phase = state.Ptr()
+		finished := state != shared.EnchantingAS && state != shared.RequeuedAS && state != shared.PreemptedAS
+		if finished {
+			// metrics are observed once per finish, a stale copy must not get that far
+			ptr.since = ench.ResourceVersion
+		}
 		if err = r.reconcileStatus(ctx, ptr); err != nil {
 			logger.Error(err, "failed to update Enchantment status", "from", ench.Status.Phase, "to", state)
 			return ctrl.Result{RequeueAfter: time.Second}, nil
 		}
-		if activeCount > 0 {
-			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		if finished {
+			observeFinished(ench, state, r.now())
+			if ptr.expiresAt != nil {
+				return ctrl.Result{RequeueAfter: ptr.expiresAt.Sub(r.now())}, nil
+			}
+			return ctrl.Result{}, nil
+		}
+		jobStates.set(client.ObjectKeyFromObject(ench), requirements)
+		// job watch events drive the next transition, only a pending retry needs a timer
+		return ctrl.Result{RequeueAfter: nextRetry}, nil
+	case shared.FailedAS, shared.CompletedAS, shared.CancelledAS:
+		r.reserved.release(client.ObjectKeyFromObject(ench))
+		if c := apimeta.FindStatusCondition(ench.Status.Conditions, enchv1.ConditionBudgetCharged); c != nil &&
+			c.Status == metav1.ConditionFalse {
+			if err = r.chargeOnce(ctx, ench); err != nil {
+				return ctrl.Result{}, err
+			}
 		}
-
-		return ctrl.Result{}, nil
-	case shared.FailedAS, shared.CompletedAS:
 		if ench.Status.ExpiresAt == nil {
-			markCompletion(ench, ptr)
+			markCompletion(ench, ptr, r.now())
+			if ptr.expiresAt == nil {
+				// no TTL, kept until someone deletes it
+				return ctrl.Result{}, nil
+			}
+			ptr.phase = phase.Ptr()
 			if err = r.reconcileStatus(ctx, ptr); err != nil {
 				logger.Error(err, "failed to update Enchantment ttl")
 				return ctrl.Result{RequeueAfter: time.Second}, nil
 			}
-			return ctrl.Result{RequeueAfter: time.Until(ench.Status.ExpiresAt.Time)}, nil
+			return ctrl.Result{RequeueAfter: ptr.expiresAt.Sub(r.now())}, nil
 		}
-		if time.Now().After(ench.Status.ExpiresAt.Time) {
+		if r.now().After(ench.Status.ExpiresAt.Time) {
+			blocked, err := r.hasBlockedDependents(ctx, ench)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if blocked {
+				// the dependents' own transitions bring us back
+				logger.Info("enchantment expired, keeping it for its dependents", "name", ench.Name)
+				return ctrl.Result{}, nil
+			}
 			policy := metav1.DeletePropagationForeground // or Background
 			if err = r.Delete(ctx, ench, &client.DeleteOptions{PropagationPolicy: &policy}); err != nil {
 				logger.Error(err, "failed to delete dependent objects", "from", ench.Status.Phase)
@@ -220,147 +452,377 @@ func (r *EnchantmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{}, nil
 		}
 		logger.Info("enchantment completed", "name", ench.Name, "last state", ench.Status.Phase)
-		return ctrl.Result{RequeueAfter: time.Until(ench.Status.ExpiresAt.Time)}, nil
+		return ctrl.Result{RequeueAfter: ench.Status.ExpiresAt.Sub(r.now())}, nil
 	}
 
 	// Job exists, update enchantment status based on job status
 	return ctrl.Result{}, nil
 }
 
-// createJob creates a new Job for the Enchantment
-func (r *EnchantmentReconciler) createJobs(ctx context.Context, enchantment *enchv1.Enchantment, ptr *ptrStatus) (ctrl.Result, error) {
+// finalize records Deleted for enchantments that never reached a terminal phase, suspends and deletes
+// the owned jobs and releases the finalizer once they are gone
+func (r *EnchantmentReconciler) finalize(ctx context.Context, ench *enchv1.Enchantment, ptr *ptrStatus) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(ench, enchantmentFinalizer) {
+		return ctrl.Result{}, nil
+	}
 
-	for i, ess := range enchantment.Spec.Artifact.Requirements {
-		jobNameStub := generateJobName(enchantment, ess.EnergyType)
-		nodeSelector := determineNodeSelector(&ess) // redundant
-		tolerations := determineTolerations(&ess)
-		suspend := true // TODO kueue expects on suspend
-		backOff := int32(0)
-
-		job := &batchv1.Job{
-			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: jobNameStub,
-				Namespace:    enchantment.Namespace,
-				Labels: map[string]string{
-					lblKeyEnergy:                           ess.EnergyType.String(),
-					lblKeyWorkload:                         "enchantment",
-					"artifact-order-id":                    strconv.Itoa(enchantment.Spec.OrderID),
-					"kueue.x-k8s.io/queue-name":            localKueue,
-					"kueue.x-k8s.io/priority-class":        enchantment.Spec.Artifact.Tier.Lower(),
-					"kueue.x-k8s.io/max-exec-time-seconds": "360",
-				},
+	switch ench.Status.Phase {
+	case shared.CompletedAS, shared.FailedAS, shared.CancelledAS, shared.DeletedAS:
+	default:
+		ptr.phase = shared.DeletedAS.Ptr()
+		ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonDeleted, "enchantment is being deleted")
+		ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonDeleted, "enchantment is being deleted")
+		markCompletion(ench, ptr, r.now())
+		if err := r.reconcileStatus(ctx, ptr); err != nil {
+			logger.Error(err, "failed to update Enchantment status", "from", ench.Status.Phase, "to", shared.DeletedAS)
+			return ctrl.Result{RequeueAfter: time.Second}, nil
+		}
+		observeFinished(ench, shared.DeletedAS, r.now())
+		r.Recorder.Eventf(ench, corev1.EventTypeNormal, "EnchantmentDeleted", "deleted in phase %s", ench.Status.Phase)
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs,
+		client.InNamespace(ench.Namespace),
+		client.MatchingFields{jobOwnerIndex: string(ench.UID)},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(jobs.Items) > 0 {
+		var suspendedCount, deletedCount int
+		policy := metav1.DeletePropagationBackground
+		for i := range jobs.Items {
+			job := &jobs.Items[i]
+			if !job.DeletionTimestamp.IsZero() {
+				continue
+			}
+			// suspend first so that kueue releases the quota and pods stop before the job goes away
+			suspended, err := r.suspendJob(ctx, job)
+			if err != nil {
+				logger.Error(err, "failed to suspend job", "job", job.Name)
+				return ctrl.Result{}, err
+			}
+			if suspended {
+				suspendedCount++
+			}
+			if err := r.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &policy}); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "failed to delete job", "job", job.Name)
+				return ctrl.Result{}, err
+			}
+			deletedCount++
+		}
+		if suspendedCount > 0 {
+			r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobsSuspended", "suspended %d jobs", suspendedCount)
+		}
+		if deletedCount > 0 {
+			r.Recorder.Eventf(ench, corev1.EventTypeNormal, "JobsDeleted", "deleted %d jobs", deletedCount)
+		}
+		// wait until jobs are gone, their delete events bring us back
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+
+	patch := client.MergeFrom(ench.DeepCopy())
+	controllerutil.RemoveFinalizer(ench, enchantmentFinalizer)
+	if err := r.Patch(ctx, ench, patch); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	logger.Info("enchantment finalized", "name", ench.Name, "last state", ench.Status.Phase)
+	return ctrl.Result{}, nil
+}
+
+// newJob builds the Job serving a single requirement from the resolved profile, attempt is recorded on it
+// for the retry policy
+func (r *EnchantmentReconciler) newJob(enchantment *enchv1.Enchantment, ess *enchv1.EnchantmentSpecArtifactRequirement,
+	profile *enchv1.EnchanterProfileSpec, attempt int) (*batchv1.Job, error) {
+	jobName := generateJobName(enchantment, ess.EnergyType, attempt)
+	nodeSelector := mergeLabels(profile.Template.NodeSelector, determineNodeSelector(ess)) // redundant
+	tolerations := append(determineTolerations(ess), profile.Template.Tolerations...)
+	suspend := true     // TODO kueue expects on suspend
+	backOff := int32(0) // retries are handled per requirement by the operator, see retryPolicy
+
+	resources := *profile.Resources.DeepCopy()
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	resources.Limits[corev1.ResourceName(ess.ResourceName)] = resource.MustParse(strconv.Itoa(ess.Limit))
+
+	env := []corev1.EnvVar{
+		{
+			Name: "POD_UID",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.uid"},
 			},
-			Spec: batchv1.JobSpec{
-				Suspend:      &suspend,
-				BackoffLimit: &backOff,
-				Template: corev1.PodTemplateSpec{
-					ObjectMeta: metav1.ObjectMeta{
-						Labels: map[string]string{
-							lblKeyEnergy:        ess.EnergyType.String(),
-							lblKeyWorkload:      "enchantment",
-							"artifact-order-id": strconv.Itoa(enchantment.Spec.OrderID),
-						},
-					},
-					Spec: corev1.PodSpec{
-						RestartPolicy: corev1.RestartPolicyNever,
-						NodeSelector:  nodeSelector,
-						Tolerations:   tolerations,
-						Containers: []corev1.Container{
-							{
-								Name:            "runesmith-enchanter",
-								Image:           r.Image,
-								ImagePullPolicy: corev1.PullIfNotPresent,
-								Ports: []corev1.ContainerPort{
-									{Name: "http", ContainerPort: 8080}, // TODO Parameterize
-								},
-								Env: []corev1.EnvVar{
-									{
-										Name: "POD_UID",
-										ValueFrom: &corev1.EnvVarSource{
-											FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.uid"},
-										},
-									},
-									{
-										Name: "POD_NAME",
-										ValueFrom: &corev1.EnvVarSource{
-											FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
-										},
-									},
-									{
-										Name: "POD_NAMESPACE",
-										ValueFrom: &corev1.EnvVarSource{
-											FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
-										},
-									},
-									{Name: "ARTIFACT_ID", Value: strconv.Itoa(enchantment.Spec.Artifact.ID)},
-									{Name: "ENCHANTMENT_COST", Value: strconv.Itoa(enchantment.Spec.Cost)},
-									{Name: "SELF_REPORT", Value: strconv.FormatBool(*enchantment.Spec.SelfReport)},
-									{Name: "HTTP_PORT", Value: "8080"},
-								},
-								Resources: corev1.ResourceRequirements{
-									Limits: corev1.ResourceList{
-										corev1.ResourceName(ess.ResourceName): resource.MustParse(strconv.Itoa(ess.Limit)),
-									},
-								},
-								LivenessProbe: &corev1.Probe{
-									ProbeHandler: corev1.ProbeHandler{
-										HTTPGet: &corev1.HTTPGetAction{
-											Path: "/healthz",
-											Port: intstr.FromInt32(8080),
-										},
-									},
-									InitialDelaySeconds: 2,
-									PeriodSeconds:       2,
-								},
-								ReadinessProbe: &corev1.Probe{
-									ProbeHandler: corev1.ProbeHandler{
-										HTTPGet: &corev1.HTTPGetAction{
-											Path: "/readyz",
-											Port: intstr.FromInt32(8080),
-										},
-									},
-									InitialDelaySeconds: 2, // TODO Parameterize
-									PeriodSeconds:       1,
-								},
+		},
+		{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		{
+			Name: "POD_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			},
+		},
+		{Name: "ARTIFACT_ID", Value: strconv.Itoa(enchantment.Spec.Artifact.ID)},
+		{Name: "ENCHANTMENT_COST", Value: strconv.Itoa(enchantment.Spec.Cost)},
+		{Name: "SELF_REPORT", Value: strconv.FormatBool(*enchantment.Spec.SelfReport)},
+		{Name: "HTTP_PORT", Value: strconv.Itoa(int(profile.Port))},
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: enchantment.Namespace,
+			Annotations: map[string]string{
+				annKeyAttempt: strconv.Itoa(attempt),
+			},
+			Labels: map[string]string{
+				lblKeyEnergy:                           ess.EnergyType.String(),
+				lblKeyWorkload:                         "enchantment",
+				"artifact-order-id":                    strconv.Itoa(enchantment.Spec.OrderID),
+				"kueue.x-k8s.io/queue-name":            profile.QueueName,
+				"kueue.x-k8s.io/priority-class":        enchantment.Spec.Artifact.Tier.Lower(),
+				"kueue.x-k8s.io/max-exec-time-seconds": strconv.Itoa(int(profile.MaxExecTimeSeconds)),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			Suspend:      &suspend,
+			BackoffLimit: &backOff,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: mergeLabels(profile.Template.Labels, map[string]string{
+						lblKeyEnergy:        ess.EnergyType.String(),
+						lblKeyWorkload:      "enchantment",
+						"artifact-order-id": strconv.Itoa(enchantment.Spec.OrderID),
+					}),
+					Annotations: profile.Template.Annotations,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					NodeSelector:       nodeSelector,
+					Tolerations:        tolerations,
+					ServiceAccountName: profile.ServiceAccountName,
+					PriorityClassName:  profile.Template.PriorityClassName,
+					ImagePullSecrets:   profile.Template.ImagePullSecrets,
+					SecurityContext:    profile.Template.SecurityContext,
+					SchedulingGates:    gangSchedulingGates(enchantment),
+					Containers: []corev1.Container{
+						{
+							Name:            "runesmith-enchanter",
+							Image:           profile.Image,
+							ImagePullPolicy: profile.ImagePullPolicy,
+							Ports: []corev1.ContainerPort{
+								{Name: "http", ContainerPort: profile.Port},
 							},
+							Env:            append(env, profile.Template.Env...),
+							Resources:      resources,
+							LivenessProbe:  profile.LivenessProbe,
+							ReadinessProbe: profile.ReadinessProbe,
 						},
 					},
 				},
 			},
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(enchantment, job, r.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// createJobs creates the Jobs of the requirements that don't have one yet. Job names are deterministic so
+// a repeated call finds the Jobs of an interrupted one instead of duplicating them.
+func (r *EnchantmentReconciler) createJobs(ctx context.Context, enchantment *enchv1.Enchantment,
+	existing map[shared.Elemental]*batchv1.Job, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	total := len(enchantment.Spec.Artifact.Requirements)
+
+	profile, err := r.resolveProfile(ctx, enchantment)
+	if errors.IsNotFound(err) {
+		msg := fmt.Sprintf("EnchanterProfile %q not found", r.profileName(enchantment))
+		r.Recorder.Event(enchantment, corev1.EventTypeWarning, enchv1.ReasonProfileNotFound, msg)
+		ptr.phase = shared.ScheduledAS.Ptr()
+		ptr.setCondition(enchv1.ConditionJobsCreated, metav1.ConditionFalse, enchv1.ReasonProfileNotFound, msg)
+		ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonProfileNotFound, msg)
+		if statusErr := r.reconcileStatus(ctx, ptr); statusErr != nil {
+			logger.Error(statusErr, "Failed to update Enchantment status")
+			return ctrl.Result{}, statusErr
 		}
+		// the profile watch brings us back once it is created
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to get EnchanterProfile")
+		return ctrl.Result{}, err
+	}
 
-		if err := controllerutil.SetControllerReference(enchantment, job, r.Scheme); err != nil {
+	var created int
+	for _, ess := range enchantment.Spec.Artifact.Requirements {
+		if _, ok := existing[ess.EnergyType]; ok {
+			continue
+		}
+		job, err := r.newJob(enchantment, &ess, profile, 1)
+		if err != nil {
 			logger.Error(err, "Failed to set owner reference on Job")
 			return ctrl.Result{}, err
 		}
 
-		if err := r.Create(ctx, job); err != nil {
+		err = r.Create(ctx, job)
+		if errors.IsAlreadyExists(err) {
+			// created by an earlier reconcile that didn't get to record it
+			continue
+		}
+		if err != nil {
+			jobCreateFailures.WithLabelValues(ess.EnergyType.String()).Inc()
 			r.Recorder.Eventf(enchantment, corev1.EventTypeWarning, "JobCreateFailed", "Error: %v", err)
-			logger.Error(err, "Failed to create Job")
+			logger.Error(err, "Failed to create Job", "energy", ess.EnergyType)
 
-			progress := fmt.Sprintf("%d/%d", i+1, len(enchantment.Spec.Artifact.Requirements))
-			ptr.phase = shared.FailedAS.Ptr()
+			progress := fmt.Sprintf("%d/%d", len(existing)+created, total)
 			ptr.progress = &progress
-			markCompletion(enchantment, ptr)
+			ptr.setCondition(enchv1.ConditionJobsCreated, metav1.ConditionFalse, enchv1.ReasonJobCreateFailed, err.Error())
+			if !isPermanentError(err) {
+				// keep the jobs created so far, the requeue creates the rest
+				ptr.phase = shared.ScheduledAS.Ptr()
+				if statusErr := r.reconcileStatus(ctx, ptr); statusErr != nil {
+					logger.Error(statusErr, "Failed to update Enchantment status")
+				}
+				return ctrl.Result{}, err
+			}
+
+			ptr.phase = shared.FailedAS.Ptr()
+			ptr.markFailed(enchv1.ReasonJobCreateFailed, err.Error())
+			markCompletion(enchantment, ptr, r.now())
 			if statusErr := r.reconcileStatus(ctx, ptr); statusErr != nil {
 				logger.Error(statusErr, "Failed to update Enchantment status")
+				return ctrl.Result{}, statusErr
 			}
-			return ctrl.Result{}, err
+			observeFinished(enchantment, shared.FailedAS, r.now())
+			// retrying won't make an invalid job valid
+			return ctrl.Result{}, nil
 		}
-		r.Recorder.Eventf(enchantment, corev1.EventTypeNormal, "JobsCreated", "created %d jobs", len(enchantment.Spec.Artifact.Requirements))
+		created++
 		logger.Info("Successfully created Job", "job", job.Name)
 	}
+	if created > 0 {
+		r.Recorder.Eventf(enchantment, corev1.EventTypeNormal, "JobsCreated", "created %d jobs", created)
+	}
 
-	progress := fmt.Sprintf("%d/%d", 0, len(enchantment.Spec.Artifact.Requirements))
+	progress := fmt.Sprintf("%d/%d", 0, total)
 	ptr.progress = &progress
 	ptr.phase = shared.ScheduledAS.Ptr()
+	ptr.setCondition(enchv1.ConditionJobsCreated, metav1.ConditionTrue, enchv1.ReasonJobsCreated,
+		fmt.Sprintf("created %d jobs", total))
+	ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonWaitingForAdmission, "jobs are queued")
+	ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonWaitingForAdmission, "jobs are queued")
 	if err := r.reconcileStatus(ctx, ptr); err != nil {
 		logger.Error(err, "Failed to update Enchantment status")
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	// kueue unsuspending the jobs is a watched change, no need to poll for admission
+	return ctrl.Result{}, nil
+}
+
+// reconcileQueued refreshes the requirement status of an Enchantment whose jobs wait for admission. A gang
+// with some of its jobs admitted waits in WaitingForGang. The status is only patched when kueue reported
+// something new.
+func (r *EnchantmentReconciler) reconcileQueued(ctx context.Context, ench *enchv1.Enchantment,
+	jobs map[shared.Elemental]*batchv1.Job, ptr *ptrStatus) (ctrl.Result, error) {
+	view, err := r.listWorkloads(ctx, ench.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	requirements := make([]enchv1.EnchantmentRequirementStatus, 0, len(jobs))
+	for _, ess := range ench.Spec.Artifact.Requirements {
+		job, ok := jobs[ess.EnergyType]
+		if !ok {
+			continue
+		}
+		rs, err := r.requirementStatus(ctx, job, view)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		requirements = append(requirements, rs)
+	}
+
+	phase := shared.ScheduledAS
+	if admitted := admittedJobs(jobs); ench.Spec.GangScheduling && admitted > 0 {
+		phase = shared.WaitingForGangAS
+		msg := fmt.Sprintf("%d/%d jobs admitted, holding their pods for the rest", admitted, len(jobs))
+		ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonGangIncomplete, msg)
+		ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonGangIncomplete, msg)
+	} else if ench.Status.Phase == shared.WaitingForGangAS {
+		// kueue took the quota of the admitted jobs back
+		ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonWaitingForAdmission, "jobs are queued")
+		ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonWaitingForAdmission, "jobs are queued")
+	}
+	if phase == ench.Status.Phase && equality.Semantic.DeepEqual(requirements, ench.Status.Requirements) {
+		return ctrl.Result{}, nil
+	}
+	if phase == shared.WaitingForGangAS && ench.Status.Phase != shared.WaitingForGangAS {
+		r.Recorder.Eventf(ench, corev1.EventTypeNormal, "WaitingForGang", "%d/%d jobs admitted, waiting for the rest",
+			admittedJobs(jobs), len(jobs))
+	}
+
+	ptr.phase = phase.Ptr()
+	ptr.requirements = requirements
+	if err = r.reconcileStatus(ctx, ptr); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update queued Enchantment status")
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	jobStates.set(client.ObjectKeyFromObject(ench), requirements)
+	return ctrl.Result{}, nil
+}
+
+// requirementStatus describes the job of a single requirement, node is resolved from the job's latest pod
+// and admission details from its kueue Workload
+func (r *EnchantmentReconciler) requirementStatus(ctx context.Context, job *batchv1.Job, view *workloadView) (enchv1.EnchantmentRequirementStatus, error) {
+	rs := enchv1.EnchantmentRequirementStatus{
+		EnergyType: shared.Elemental(job.Labels[lblKeyEnergy]),
+		JobName:    job.Name,
+		State:      requirementState(job),
+		StartTime:  job.Status.StartTime,
+		FinishTime: jobFinishTime(job),
+		Attempts:   jobAttempt(job),
+	}
+	view.apply(&rs, job)
+	if rs.State == enchv1.RequirementSuspended || rs.State == enchv1.RequirementPreempted {
+		return rs, nil
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{batchv1.JobNameLabel: job.Name},
+	); err != nil {
+		return rs, err
+	}
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		if pods.Items[i].Spec.NodeName == "" {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&pods.Items[i].CreationTimestamp) {
+			latest = &pods.Items[i]
+		}
+	}
+	if latest != nil {
+		rs.NodeName = latest.Spec.NodeName
+	}
+	return rs, nil
+}
+
+func (r *EnchantmentReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
 }
 
 // reconcileStatus patches sub resource Status. status.Phase is required
@@ -373,6 +835,10 @@ func (r *EnchantmentReconciler) reconcileStatus(ctx context.Context, p *ptrStatu
 		if err := r.Client.Get(ctx, p.namespacedName, &ench); err != nil {
 			return err
 		}
+		if p.since != "" && ench.ResourceVersion != p.since {
+			return errors.NewConflict(enchv1.GroupVersion.WithResource("enchantments").GroupResource(), ench.Name,
+				fmt.Errorf("status moved on since resourceVersion %s", p.since))
+		}
 		original := ench.DeepCopy()
 
 		ench.Status.Phase = *p.phase
@@ -394,8 +860,29 @@ func (r *EnchantmentReconciler) reconcileStatus(ctx context.Context, p *ptrStatu
 		if p.successful != nil {
 			ench.Status.SucceededJobs = *p.successful
 		}
+		if p.requirements != nil {
+			ench.Status.Requirements = p.requirements
+		}
+		if p.artifact != nil {
+			ench.Status.Artifact = p.artifact
+		}
+		if p.generation > 0 {
+			ench.Status.ObservedGeneration = p.generation
+		}
+		for _, c := range p.conditions {
+			c.ObservedGeneration = ench.Status.ObservedGeneration
+			if !c.LastTransitionTime.IsZero() {
+				// SetStatusCondition keeps the transition time of an unchanged status
+				apimeta.RemoveStatusCondition(&ench.Status.Conditions, c.Type)
+			}
+			apimeta.SetStatusCondition(&ench.Status.Conditions, c)
+		}
 
-		return r.Client.Status().Patch(ctx, &ench, client.MergeFrom(original))
+		patch := client.MergeFrom(original)
+		if p.since != "" {
+			patch = client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})
+		}
+		return r.Client.Status().Patch(ctx, &ench, patch)
 	}); err != nil {
 		return err
 	}
@@ -403,9 +890,14 @@ func (r *EnchantmentReconciler) reconcileStatus(ctx context.Context, p *ptrStatu
 	return nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
-func (r *EnchantmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(),
+// PodCacheSelector limits the manager's pod cache to enchanter pods, the operator only reads those.
+func PodCacheSelector() labels.Selector {
+	return labels.SelectorFromSet(labels.Set{lblKeyWorkload: "enchantment"})
+}
+
+// SetupIndexes registers the cache indexes the reconciler lists by.
+func (r *EnchantmentReconciler) SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx,
 		&batchv1.Job{}, jobOwnerIndex,
 		func(obj client.Object) []string {
 			j := obj.(*batchv1.Job)
@@ -418,11 +910,80 @@ func (r *EnchantmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		}); err != nil {
 		return err
 	}
+	if err := indexer.IndexField(ctx,
+		&enchv1.Enchantment{}, profileIndex,
+		func(obj client.Object) []string {
+			if name := r.profileName(obj.(*enchv1.Enchantment)); name != "" {
+				return []string{name}
+			}
+			return nil
+		}); err != nil {
+		return err
+	}
+	if err := setupDependencyIndexes(ctx, indexer); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx,
+		&enchv1.Enchantment{}, recipeIndex,
+		func(obj client.Object) []string {
+			if name := obj.(*enchv1.Enchantment).Spec.Recipe; name != "" {
+				return []string{name}
+			}
+			return nil
+		})
+}
+
+// jobChangedPredicate passes the Job events that can move an Enchantment: creation, deletion,
+// suspend flips and status changes. Metadata-only updates and resyncs are dropped.
+func jobChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldJob, ok := e.ObjectOld.(*batchv1.Job)
+			if !ok {
+				return true
+			}
+			newJob, ok := e.ObjectNew.(*batchv1.Job)
+			if !ok {
+				return true
+			}
+			return !equality.Semantic.DeepEqual(oldJob.Spec.Suspend, newJob.Spec.Suspend) ||
+				!oldJob.DeletionTimestamp.Equal(newJob.DeletionTimestamp) ||
+				!equality.Semantic.DeepEqual(oldJob.Status, newJob.Status)
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EnchantmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.SetupIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+	if r.Clock == nil {
+		r.Clock = realClock{}
+	}
 	r.Recorder = mgr.GetEventRecorderFor("runesmith-operator")
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&enchv1.Enchantment{}).
-		Owns(&batchv1.Job{}).
-		Named("runesmith-operator").
-		Complete(r)
+		Owns(&batchv1.Job{}, builder.WithPredicates(jobChangedPredicate())).
+		Watches(&enchv1.EnchanterProfile{}, handler.EnqueueRequestsFromMapFunc(r.enchantmentsForProfile)).
+		Watches(&enchv1.Recipe{}, handler.EnqueueRequestsFromMapFunc(r.enchantmentsForRecipe)).
+		Watches(&enchv1.Enchantment{}, handler.EnqueueRequestsFromMapFunc(r.enchantmentsForDependency),
+			builder.WithPredicates(phaseChangedPredicate())).
+		Watches(&enchv1.Enchantment{}, handler.EnqueueRequestsFromMapFunc(r.enchantmentsOverBudget),
+			builder.WithPredicates(phaseChangedPredicate())).
+		Watches(&enchv1.ManaBudget{}, handler.EnqueueRequestsFromMapFunc(r.enchantmentsOverBudget))
+
+	// kueue is optional for the operator to start, without it only Job.Spec.Suspend tells about admission
+	gk := schema.GroupKind{Group: kueue.GroupVersion.Group, Kind: "Workload"}
+	if _, err := mgr.GetRESTMapper().RESTMapping(gk, kueue.GroupVersion.Version); err == nil {
+		r.kueueEnabled = true
+		b = b.Watches(&kueue.Workload{}, handler.EnqueueRequestsFromMapFunc(r.enchantmentsForWorkload),
+			builder.WithPredicates(workloadChangedPredicate()))
+	} else {
+		mgr.GetLogger().Info("kueue Workload API not found, not watching workloads", "error", err.Error())
+	}
+
+	return b.Named("runesmith-operator").Complete(r)
 }
@@ -18,67 +18,1405 @@ package controller
 
 import (
 	"context"
+	"time"
 
+	"github.com/fukaraca/runesmith/shared"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"k8s.io/apimachinery/pkg/api/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/components/runesmith-operator/internal/kueue"
 )
 
+// jobCreateFailingClient fails every Job create with err, the API server can't be talked into that
+type jobCreateFailingClient struct {
+	client.Client
+	err error
+}
+
+func (c *jobCreateFailingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*batchv1.Job); ok {
+		return c.err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// frostfireStaff needs one mana of each energy
+func frostfireStaff(energies ...shared.Elemental) enchantmentv1.EnchantmentSpecArtifact {
+	reqs := make([]enchantmentv1.EnchantmentSpecArtifactRequirement, 0, len(energies))
+	for _, energy := range energies {
+		reqs = append(reqs, enchantmentv1.EnchantmentSpecArtifactRequirement{
+			EnergyType: energy, ResourceName: energy.Resource(), Limit: 1,
+		})
+	}
+	return enchantmentv1.EnchantmentSpecArtifact{ID: 2, Name: "Frostfire Staff", Tier: shared.Rare, Requirements: reqs}
+}
+
 var _ = Describe("Enchantment Controller", func() {
-	Context("When reconciling a resource", func() {
-		const resourceName = "test-resource"
+	var reconciler *EnchantmentReconciler
+
+	// ownedJob is the only job of the Enchantment
+	ownedJob := func(g Gomega, key types.NamespacedName) *batchv1.Job {
+		jobs := ownedJobs(g, key)
+		g.Expect(jobs).To(HaveLen(1))
+		return &jobs[0]
+	}
+	// patchSpec changes the spec the way the backend does and waits for the cache to catch up
+	patchSpec := func(key types.NamespacedName, mutate func(spec *enchantmentv1.EnchantmentSpec)) {
+		ench := &enchantmentv1.Enchantment{}
+		Expect(k8sClient.Get(ctx, key, ench)).To(Succeed())
+		patch := client.MergeFrom(ench.DeepCopy())
+		mutate(&ench.Spec)
+		Expect(k8sClient.Patch(ctx, ench, patch)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(fetchEnchantment(g, key).Generation).To(Equal(ench.Generation))
+		}).Should(Succeed())
+	}
+	// setPhase moves the Enchantment to a phase the way its own reconciler would
+	setPhase := func(key types.NamespacedName, phase shared.EnchantmentPhase) {
+		ench := &enchantmentv1.Enchantment{}
+		Expect(k8sClient.Get(ctx, key, ench)).To(Succeed())
+		ench.Status.Phase = phase
+		Expect(k8sClient.Status().Update(ctx, ench)).To(Succeed())
+		expectEnchantmentPhase(key, phase)
+	}
 
-		ctx := context.Background()
+	BeforeEach(func() {
+		reconciler = newEnchantmentReconciler()
+	})
+
+	Context("When driven by its jobs", func() {
+		const (
+			resourceName = "transitions"
+			ttlSeconds   = 60
+		)
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+		var clock *fakeClock
 
-		typeNamespacedName := types.NamespacedName{
-			Name:      resourceName,
-			Namespace: "default", // TODO(user):Modify as needed
+		// updateJobs changes every owned job the way kueue and the job controller would and waits for the cache
+		updateJobs := func(mutate func(job *batchv1.Job), mutateStatus func(status *batchv1.JobStatus)) {
+			var jobs []batchv1.Job
+			Eventually(func(g Gomega) { jobs = ownedJobs(g, key) }).Should(Succeed())
+			Expect(jobs).NotTo(BeEmpty())
+			versions := make(map[string]string, len(jobs))
+			for i := range jobs {
+				job := &jobs[i]
+				if mutate != nil {
+					mutate(job)
+					Expect(k8sClient.Update(ctx, job)).To(Succeed())
+				}
+				if mutateStatus != nil {
+					mutateStatus(&job.Status)
+					Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+				}
+				versions[job.Name] = job.ResourceVersion
+			}
+			Eventually(func(g Gomega) {
+				for _, job := range ownedJobs(g, key) {
+					g.Expect(job.ResourceVersion).To(Equal(versions[job.Name]))
+				}
+			}).Should(Succeed())
+		}
+		suspendJobs := func(suspend bool) {
+			updateJobs(func(job *batchv1.Job) { job.Spec.Suspend = &suspend }, nil)
+		}
+		// runJobs unsuspends the jobs and starts their pods
+		runJobs := func() {
+			suspendJobs(false)
+			updateJobs(nil, func(status *batchv1.JobStatus) {
+				now := metav1.Now()
+				status.StartTime = &now
+				status.Active = 1
+			})
+		}
+		// createdJobs takes the Enchantment to Scheduled with all of its jobs created
+		createdJobs := func(n int) {
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) { g.Expect(ownedJobs(g, key)).To(HaveLen(n)) }).Should(Succeed())
+			expectEnchantmentPhase(key, shared.ScheduledAS)
+		}
+		create := func(energies ...shared.Elemental) {
+			ench := newEnchantment(resourceName, 20)
+			ench.Spec.Artifact = frostfireStaff(energies...)
+			createEnchantment(ench)
 		}
-		enchantment := &enchantmentv1.Enchantment{}
 
 		BeforeEach(func() {
-			By("creating the custom resource for the Kind Enchantment")
-			err := k8sClient.Get(ctx, typeNamespacedName, enchantment)
-			if err != nil && errors.IsNotFound(err) {
-				resource := &enchantmentv1.Enchantment{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
-					},
-					// TODO(user): Specify other spec details if needed.
+			clock = &fakeClock{now: time.Now()}
+			reconciler.Clock = clock
+		})
+
+		AfterEach(func() {
+			removeEnchantments(resourceName)
+		})
+
+		Context("When the jobs are admitted", func() {
+			BeforeEach(func() {
+				create(shared.FireEnergy, shared.FrostEnergy)
+				createdJobs(2)
+			})
+
+			It("should stay Scheduled while the jobs are suspended", func() {
+				reconcileEnchantment(reconciler, key)
+				expectEnchantmentPhase(key, shared.ScheduledAS)
+			})
+
+			It("should go Enchanting once the jobs are unsuspended", func() {
+				runJobs()
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.EnchantingAS))
+					g.Expect(apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchantmentv1.ConditionAdmitted)).To(BeTrue())
+					g.Expect(apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchantmentv1.ConditionProgressing)).To(BeTrue())
+				}).Should(Succeed())
+			})
+
+			It("should be Requeued once the jobs are suspended again", func() {
+				runJobs()
+				reconcileEnchantment(reconciler, key)
+				expectEnchantmentPhase(key, shared.EnchantingAS)
+
+				suspendJobs(true)
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.RequeuedAS))
+					cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionAdmitted)
+					g.Expect(cond).NotTo(BeNil())
+					g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonRequeued))
+				}).Should(Succeed())
+
+				By("going back to Enchanting once they are admitted again")
+				suspendJobs(false)
+				reconcileEnchantment(reconciler, key)
+				expectEnchantmentPhase(key, shared.EnchantingAS)
+			})
+		})
+
+		Context("When the jobs finish", func() {
+			BeforeEach(func() {
+				create(shared.FireEnergy, shared.FrostEnergy)
+				createdJobs(2)
+				runJobs()
+				reconcileEnchantment(reconciler, key)
+				expectEnchantmentPhase(key, shared.EnchantingAS)
+			})
+
+			It("should complete once all jobs succeeded", func() {
+				updateJobs(nil, func(status *batchv1.JobStatus) {
+					status.Active = 0
+					status.Succeeded = 1
+				})
+				res := reconcileEnchantment(reconciler, key)
+				Expect(res.RequeueAfter).To(BeNumerically("~", ttlSeconds*time.Second, time.Second))
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.CompletedAS))
+					g.Expect(ench.Status.Progress).To(Equal("2/2"))
+					g.Expect(ench.Status.SucceededJobs).To(Equal(2))
+					g.Expect(apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchantmentv1.ConditionSucceeded)).To(BeTrue())
+					g.Expect(ench.Status.CompletionTime).NotTo(BeNil())
+					g.Expect(ench.Status.CompletionTime.Time).To(BeTemporally("~", clock.now, time.Second))
+					g.Expect(ench.Status.ExpiresAt).NotTo(BeNil())
+					g.Expect(ench.Status.ExpiresAt.Time).To(BeTemporally("~", clock.now.Add(ttlSeconds*time.Second), time.Second))
+				}).Should(Succeed())
+			})
+
+			It("should keep Enchanting while only some jobs succeeded", func() {
+				var jobs []batchv1.Job
+				Eventually(func(g Gomega) { jobs = ownedJobs(g, key) }).Should(Succeed())
+				job := &jobs[0]
+				job.Status.Active = 0
+				job.Status.Succeeded = 1
+				Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+				Eventually(func(g Gomega) {
+					var cached batchv1.Job
+					g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(job), &cached)).To(Succeed())
+					g.Expect(cached.Status.Succeeded).To(Equal(int32(1)))
+				}).Should(Succeed())
+
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.EnchantingAS))
+					g.Expect(ench.Status.Progress).To(Equal("1/2"))
+				}).Should(Succeed())
+			})
+
+			It("should not complete while a requirement has no job", func() {
+				var jobs []batchv1.Job
+				Eventually(func(g Gomega) { jobs = ownedJobs(g, key) }).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, &jobs[1], client.PropagationPolicy(metav1.DeletePropagationBackground))).To(Succeed())
+				job := &jobs[0]
+				job.Status.Active = 0
+				job.Status.Succeeded = 1
+				Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+				Eventually(func(g Gomega) {
+					g.Expect(ownedJobs(g, key)).To(HaveLen(1))
+					var cached batchv1.Job
+					g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(job), &cached)).To(Succeed())
+					g.Expect(cached.Status.Succeeded).To(Equal(int32(1)))
+				}).Should(Succeed())
+
+				reconcileEnchantment(reconciler, key)
+				Consistently(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.EnchantingAS))
+					g.Expect(ench.Status.Progress).To(Equal("1/2"))
+				}, "2s").Should(Succeed())
+			})
+
+			It("should fail once a job failed", func() {
+				updateJobs(nil, func(status *batchv1.JobStatus) {
+					status.Active = 0
+					status.Failed = 1
+				})
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+					g.Expect(ench.Status.ExpiresAt).NotTo(BeNil())
+					cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+					g.Expect(cond).NotTo(BeNil())
+					g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonJobFailed))
+				}).Should(Succeed())
+			})
+
+			It("should fail once its jobs vanished", func() {
+				for _, job := range ownedJobs(Default, key) {
+					Expect(k8sClient.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationBackground))).To(Succeed())
 				}
-				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
-			}
+				Eventually(func(g Gomega) { g.Expect(ownedJobs(g, key)).To(BeEmpty()) }).Should(Succeed())
+
+				res := reconcileEnchantment(reconciler, key)
+				Expect(res.RequeueAfter).To(BeNumerically("~", ttlSeconds*time.Second, time.Second))
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+					cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+					g.Expect(cond).NotTo(BeNil())
+					g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonJobsVanished))
+				}).Should(Succeed())
+			})
+		})
+
+		Context("When a finished Enchantment expires", func() {
+			BeforeEach(func() {
+				create(shared.FireEnergy)
+				createdJobs(1)
+				runJobs()
+				reconcileEnchantment(reconciler, key)
+				expectEnchantmentPhase(key, shared.EnchantingAS)
+				updateJobs(nil, func(status *batchv1.JobStatus) {
+					status.Active = 0
+					status.Succeeded = 1
+				})
+				reconcileEnchantment(reconciler, key)
+				expectEnchantmentPhase(key, shared.CompletedAS)
+			})
+
+			It("should keep it until its TTL passed and delete it then", func() {
+				clock.now = clock.now.Add(ttlSeconds * time.Second / 2)
+				res := reconcileEnchantment(reconciler, key)
+				Expect(res.RequeueAfter).To(BeNumerically("~", ttlSeconds*time.Second/2, time.Second))
+				Expect(fetchEnchantment(Default, key).DeletionTimestamp).To(BeNil())
+
+				clock.now = clock.now.Add(ttlSeconds * time.Second)
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					var ench enchantmentv1.Enchantment
+					err := cachedClient.Get(ctx, key, &ench)
+					if apierrors.IsNotFound(err) {
+						return
+					}
+					g.Expect(err).NotTo(HaveOccurred())
+					g.Expect(ench.DeletionTimestamp).NotTo(BeNil())
+				}).Should(Succeed())
+			})
+
+			It("should record the TTL of a finished Enchantment that has none", func() {
+				ench := &enchantmentv1.Enchantment{}
+				Expect(k8sClient.Get(ctx, key, ench)).To(Succeed())
+				completed := ench.Status.CompletionTime.DeepCopy()
+				ench.Status.ExpiresAt = nil
+				Expect(k8sClient.Status().Update(ctx, ench)).To(Succeed())
+				Eventually(func(g Gomega) { g.Expect(fetchEnchantment(g, key).Status.ExpiresAt).To(BeNil()) }).Should(Succeed())
+
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.CompletedAS))
+					g.Expect(ench.Status.ExpiresAt).NotTo(BeNil())
+					g.Expect(ench.Status.ExpiresAt.Time).To(BeTemporally("==", completed.Add(ttlSeconds*time.Second)))
+				}).Should(Succeed())
+			})
 		})
 
+		Context("When creating a job fails", func() {
+			BeforeEach(func() {
+				create(shared.FireEnergy)
+			})
+
+			It("should stay Scheduled and retry on a transient error", func() {
+				reconciler.Client = &jobCreateFailingClient{Client: cachedClient,
+					err: apierrors.NewServiceUnavailable("etcd is catching its breath")}
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.ScheduledAS))
+					cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionJobsCreated)
+					g.Expect(cond).NotTo(BeNil())
+					g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonJobCreateFailed))
+				}).Should(Succeed())
+				Expect(reconciler.Recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("JobCreateFailed")))
+
+				By("creating the job once the API server recovers")
+				reconciler.Client = cachedClient
+				createdJobs(1)
+			})
+
+			It("should fail on an error a retry can't fix", func() {
+				reconciler.Client = &jobCreateFailingClient{Client: cachedClient,
+					err: apierrors.NewInvalid(schema.GroupKind{Group: batchv1.GroupName, Kind: "Job"}, "ejob",
+						field.ErrorList{field.Required(field.NewPath("spec", "template", "spec", "containers").Index(0).Child("image"), "")})}
+				reconcileEnchantment(reconciler, key)
+				Eventually(func(g Gomega) {
+					ench := fetchEnchantment(g, key)
+					g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+					g.Expect(ench.Status.ExpiresAt).NotTo(BeNil())
+					cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+					g.Expect(cond).NotTo(BeNil())
+					g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonJobCreateFailed))
+				}).Should(Succeed())
+			})
+		})
+	})
+
+	Context("When a previous reconcile created only part of the jobs", func() {
+		const resourceName = "partial-jobs"
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
 		AfterEach(func() {
-			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &enchantmentv1.Enchantment{}
-			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			removeEnchantments(resourceName)
+		})
+
+		It("should create only the missing jobs, once", func() {
+			ench := newEnchantment(resourceName, 9)
+			ench.Spec.Artifact = frostfireStaff(shared.FireEnergy, shared.FrostEnergy)
+			createEnchantment(ench)
+
+			By("leaving the fire job behind as an interrupted reconcile would")
+			profile, err := reconciler.resolveProfile(ctx, ench)
+			Expect(err).NotTo(HaveOccurred())
+			fire, err := reconciler.newJob(ench, &ench.Spec.Artifact.Requirements[0], profile, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Create(ctx, fire)).To(Succeed())
+			Eventually(func(g Gomega) { g.Expect(ownedJobs(g, key)).To(HaveLen(1)) }).Should(Succeed())
+
+			for range 2 {
+				reconcileEnchantment(reconciler, key)
+			}
+
+			Eventually(func(g Gomega) {
+				var names []string
+				for _, job := range ownedJobs(g, key) {
+					names = append(names, job.Name)
+				}
+				g.Expect(names).To(ConsistOf(
+					generateJobName(ench, shared.FireEnergy, 1),
+					generateJobName(ench, shared.FrostEnergy, 1),
+				))
+			}).Should(Succeed())
+
+			Expect(k8sClient.Get(ctx, key, ench)).To(Succeed())
+			Expect(ench.Status.Phase).To(Equal(shared.ScheduledAS))
+		})
+	})
+
+	Context("When resolving the queue", func() {
+		// enchantmentIn is never created, resolveProfile only looks at its namespace
+		enchantmentIn := func(namespace string) *enchantmentv1.Enchantment {
+			return &enchantmentv1.Enchantment{ObjectMeta: metav1.ObjectMeta{Name: "queued", Namespace: namespace}}
+		}
+		createNamespace := func(ns *corev1.Namespace) {
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			Eventually(func() error {
+				return cachedClient.Get(ctx, client.ObjectKeyFromObject(ns), &corev1.Namespace{})
+			}).Should(Succeed())
+		}
+
+		It("should use the default queue for namespaces that don't name one", func() {
+			profile, err := reconciler.resolveProfile(ctx, enchantmentIn("default"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.QueueName).To(Equal(localKueue))
+
+			reconciler.DefaultQueue = "shared-queue"
+			profile, err = reconciler.resolveProfile(ctx, enchantmentIn("default"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.QueueName).To(Equal("shared-queue"))
+		})
+
+		It("should take the queue from the namespace annotation over its label", func() {
+			createNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "team-annotated",
+				Annotations: map[string]string{annKeyLocalQueue: "annotated-queue"},
+				Labels:      map[string]string{annKeyLocalQueue: "labelled-queue"},
+			}})
+			profile, err := reconciler.resolveProfile(ctx, enchantmentIn("team-annotated"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.QueueName).To(Equal("annotated-queue"))
+		})
+
+		It("should take the queue from the namespace label", func() {
+			createNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   "team-labelled",
+				Labels: map[string]string{annKeyLocalQueue: "labelled-queue"},
+			}})
+			profile, err := reconciler.resolveProfile(ctx, enchantmentIn("team-labelled"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.QueueName).To(Equal("labelled-queue"))
+		})
+
+		It("should keep the queue a profile names", func() {
+			createNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "team-profiled",
+				Annotations: map[string]string{annKeyLocalQueue: "annotated-queue"},
+			}})
+			profile := &enchantmentv1.EnchanterProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "queue-pinned"},
+				Spec:       enchantmentv1.EnchanterProfileSpec{QueueName: "pinned-queue"},
+			}
+			Expect(k8sClient.Create(ctx, profile)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, profile)).To(Succeed()) })
+			Eventually(func() error {
+				return cachedClient.Get(ctx, client.ObjectKeyFromObject(profile), &enchantmentv1.EnchanterProfile{})
+			}).Should(Succeed())
+
+			reconciler.DefaultProfile = profile.Name
+			spec, err := reconciler.resolveProfile(ctx, enchantmentIn("team-profiled"))
 			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.QueueName).To(Equal("pinned-queue"))
+		})
+	})
+
+	Context("When filtering Job updates", func() {
+		pred := jobChangedPredicate()
+		newJob := func() *batchv1.Job {
+			suspend := true
+			return &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "ejob-1-fire-abc", Namespace: "default"},
+				Spec:       batchv1.JobSpec{Suspend: &suspend},
+			}
+		}
+
+		It("should drop metadata only updates", func() {
+			oldJob, job := newJob(), newJob()
+			job.Labels = map[string]string{"kueue.x-k8s.io/queue-name": "other"}
+			job.ResourceVersion = "2"
+			Expect(pred.Update(event.UpdateEvent{ObjectOld: oldJob, ObjectNew: job})).To(BeFalse())
+		})
+
+		It("should pass suspend flips", func() {
+			oldJob, job := newJob(), newJob()
+			resume := false
+			job.Spec.Suspend = &resume
+			Expect(pred.Update(event.UpdateEvent{ObjectOld: oldJob, ObjectNew: job})).To(BeTrue())
+		})
+
+		It("should pass status changes", func() {
+			oldJob, job := newJob(), newJob()
+			job.Status.Active = 1
+			Expect(pred.Update(event.UpdateEvent{ObjectOld: oldJob, ObjectNew: job})).To(BeTrue())
+		})
+
+		It("should pass creations and deletions but not generic events", func() {
+			Expect(pred.Create(event.CreateEvent{Object: newJob()})).To(BeTrue())
+			Expect(pred.Delete(event.DeleteEvent{Object: newJob()})).To(BeTrue())
+			Expect(pred.Generic(event.GenericEvent{Object: newJob()})).To(BeFalse())
+		})
+	})
+
+	Context("When jobs report progress", func() {
+		const resourceName = "event-driven"
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			createEnchantment(newEnchantment(resourceName, 8))
+		})
+
+		AfterEach(func() {
+			removeEnchantments(resourceName)
+		})
+
+		It("should move through the phases without a requeue timer", func() {
+			By("creating the jobs")
+			res := reconcileEnchantment(reconciler, key)
+			Expect(res.RequeueAfter).To(BeZero())
+			expectEnchantmentPhase(key, shared.ScheduledAS)
+
+			var job *batchv1.Job
+			Eventually(func(g Gomega) { job = ownedJob(g, key) }).Should(Succeed())
+
+			By("admitting the jobs the way kueue does")
+			startJob(job)
 
-			By("Cleanup the specific resource instance Enchantment")
-			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			res = reconcileEnchantment(reconciler, key)
+			Expect(res.RequeueAfter).To(BeZero())
+			expectEnchantmentPhase(key, shared.EnchantingAS)
+
+			res = reconcileEnchantment(reconciler, key)
+			Expect(res.RequeueAfter).To(BeZero(), "a running job must not be polled")
+
+			By("completing the jobs")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(job), job)).To(Succeed())
+			done := metav1.Now()
+			job.Status.Active = 0
+			job.Status.Succeeded = 1
+			job.Status.CompletionTime = &done
+			job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+				Type:               batchv1.JobComplete,
+				Status:             corev1.ConditionTrue,
+				LastProbeTime:      done,
+				LastTransitionTime: done,
+			})
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(ownedJob(g, key).Status.Succeeded).To(Equal(int32(1)))
+			}).Should(Succeed())
+
+			res = reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.CompletedAS)
+			By("only waking up again for the retention ttl")
+			Expect(res.RequeueAfter).To(BeNumerically(">", 55*time.Second))
+			Expect(res.RequeueAfter).To(BeNumerically("<=", 60*time.Second))
 		})
-		It("should successfully reconcile the resource", func() {
-			By("Reconciling the created resource")
-			controllerReconciler := &EnchantmentReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+	})
+
+	Context("When kueue tracks the jobs", func() {
+		const (
+			resourceName = "kueue-tracked"
+			clusterQueue = "runesmith-cluster-queue"
+			// neighbour is another team's namespace feeding the same ClusterQueue
+			neighbour = "kueue-neighbour"
+		)
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		// newWorkload mimics the Workload kueue creates for a suspended Job
+		newWorkload := func(name string, job *batchv1.Job, priority int32) *kueue.Workload {
+			wl := &kueue.Workload{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec:       kueue.WorkloadSpec{QueueName: localKueue, Priority: &priority},
+			}
+			if job != nil {
+				Expect(controllerutil.SetControllerReference(job, wl, cachedClient.Scheme())).To(Succeed())
+			}
+			Expect(k8sClient.Create(ctx, wl)).To(Succeed())
+			return wl
+		}
+		setWorkloadStatus := func(wl *kueue.Workload, status kueue.WorkloadStatus) {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(wl), wl)).To(Succeed())
+			wl.Status = status
+			Expect(k8sClient.Status().Update(ctx, wl)).To(Succeed())
+			Eventually(func(g Gomega) {
+				var cached kueue.Workload
+				g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(wl), &cached)).To(Succeed())
+				g.Expect(cached.Status.Conditions).To(HaveLen(len(status.Conditions)))
+			}).Should(Succeed())
+		}
+		condition := func(t string, status metav1.ConditionStatus, reason string) metav1.Condition {
+			return metav1.Condition{Type: t, Status: status, Reason: reason, LastTransitionTime: metav1.Now()}
+		}
+		createLocalQueue := func(namespace, name string) {
+			lq := &kueue.LocalQueue{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       kueue.LocalQueueSpec{ClusterQueue: clusterQueue},
 			}
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, lq))).To(Succeed())
+			Eventually(func() error { return cachedClient.Get(ctx, client.ObjectKeyFromObject(lq), &kueue.LocalQueue{}) }).Should(Succeed())
+		}
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: neighbour}}
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, ns))).To(Succeed())
+			createLocalQueue("default", localKueue)
+			createLocalQueue(neighbour, "neighbour-queue")
+
+			createEnchantment(newEnchantment(resourceName, 10))
+			reconciler.kueueEnabled = true
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.DeleteAllOf(ctx, &kueue.Workload{}, client.InNamespace("default"))).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &kueue.Workload{}, client.InNamespace(neighbour))).To(Succeed())
+			removeEnchantments(resourceName)
+		})
+
+		It("should report queue position, admission and preemption", func() {
+			By("creating the jobs")
+			reconcileEnchantment(reconciler, key)
+			var job *batchv1.Job
+			Eventually(func(g Gomega) { job = ownedJob(g, key) }).Should(Succeed())
+
+			By("queueing the workload behind a higher priority one of another namespace")
+			priority := int32(100)
+			other := &kueue.Workload{
+				ObjectMeta: metav1.ObjectMeta{Name: "job-other", Namespace: neighbour},
+				Spec:       kueue.WorkloadSpec{QueueName: "neighbour-queue", Priority: &priority},
+			}
+			Expect(k8sClient.Create(ctx, other)).To(Succeed())
+			wl := newWorkload("job-"+job.Name, job, 0)
+			Eventually(func(g Gomega) {
+				var list kueue.WorkloadList
+				g.Expect(cachedClient.List(ctx, &list)).To(Succeed())
+				g.Expect(list.Items).To(HaveLen(2))
+			}).Should(Succeed())
+
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.ScheduledAS))
+				g.Expect(ench.Status.Requirements).To(HaveLen(1))
+				rs := ench.Status.Requirements[0]
+				g.Expect(rs.Workload).To(Equal(wl.Name))
+				g.Expect(rs.QueuePosition).NotTo(BeNil())
+				g.Expect(*rs.QueuePosition).To(Equal(int32(2)))
+			}).Should(Succeed())
+
+			By("waking the Enchantment up once the Workload ahead of it is admitted")
+			setWorkloadStatus(other, kueue.WorkloadStatus{
+				Admission: &kueue.Admission{ClusterQueue: clusterQueue},
+				Conditions: []metav1.Condition{
+					condition(kueue.WorkloadQuotaReserved, metav1.ConditionTrue, "QuotaReserved"),
+					condition(kueue.WorkloadAdmitted, metav1.ConditionTrue, "Admitted"),
+				},
 			})
+			Expect(reconciler.enchantmentsForWorkload(ctx, other)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				rs := fetchEnchantment(g, key).Status.Requirements[0]
+				g.Expect(rs.QueuePosition).NotTo(BeNil())
+				g.Expect(*rs.QueuePosition).To(Equal(int32(1)))
+			}).Should(Succeed())
+
+			By("admitting the workload")
+			setWorkloadStatus(wl, kueue.WorkloadStatus{
+				Admission: &kueue.Admission{
+					ClusterQueue: clusterQueue,
+					PodSetAssignments: []kueue.PodSetAssignment{{
+						Name:    "main",
+						Flavors: map[corev1.ResourceName]string{corev1.ResourceName(shared.FireEnergy.Resource()): "volcanic"},
+					}},
+				},
+				Conditions: []metav1.Condition{
+					condition(kueue.WorkloadQuotaReserved, metav1.ConditionTrue, "QuotaReserved"),
+					condition(kueue.WorkloadAdmitted, metav1.ConditionTrue, "Admitted"),
+				},
+			})
+			startJob(job)
+
+			reconcileEnchantment(reconciler, key)
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.EnchantingAS))
+				rs := ench.Status.Requirements[0]
+				g.Expect(rs.ClusterQueue).To(Equal(clusterQueue))
+				g.Expect(rs.Flavor).To(Equal("volcanic"))
+				g.Expect(rs.AdmissionTime).NotTo(BeNil())
+				g.Expect(rs.QueuePosition).To(BeNil())
+			}).Should(Succeed())
+
+			By("evicting the workload for a higher priority one")
+			setWorkloadStatus(wl, kueue.WorkloadStatus{
+				Conditions: []metav1.Condition{
+					condition(kueue.WorkloadQuotaReserved, metav1.ConditionFalse, "Pending"),
+					condition(kueue.WorkloadAdmitted, metav1.ConditionFalse, "NoReservation"),
+					condition(kueue.WorkloadEvicted, metav1.ConditionTrue, kueue.WorkloadEvictedByPreemption),
+				},
+			})
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(job), job)).To(Succeed())
+			job.Spec.Suspend = ptr.To(true)
+			Expect(k8sClient.Update(ctx, job)).To(Succeed())
+			Eventually(func(g Gomega) { g.Expect(*ownedJob(g, key).Spec.Suspend).To(BeTrue()) }).Should(Succeed())
+
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.PreemptedAS))
+				g.Expect(ench.Status.Requirements[0].State).To(Equal(enchantmentv1.RequirementPreempted))
+			}).Should(Succeed())
+		})
+	})
+
+	Context("When gang scheduled", func() {
+		const resourceName = "gang-scheduled"
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		// admit unsuspends the job the way kueue does and waits for the cache to see it
+		admit := func(job *batchv1.Job) {
+			job.Spec.Suspend = ptr.To(false)
+			Expect(k8sClient.Update(ctx, job)).To(Succeed())
+			Eventually(func(g Gomega) {
+				var cached batchv1.Job
+				g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(job), &cached)).To(Succeed())
+				g.Expect(*cached.Spec.Suspend).To(BeFalse())
+			}).Should(Succeed())
+		}
+
+		BeforeEach(func() {
+			ench := newEnchantment(resourceName, 11)
+			ench.Spec.GangScheduling = true
+			ench.Spec.Artifact = frostfireStaff(shared.FireEnergy, shared.FrostEnergy)
+			createEnchantment(ench)
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace("default"),
+				client.MatchingLabels{lblKeyWorkload: "enchantment"})).To(Succeed())
+			removeEnchantments(resourceName)
+		})
+
+		It("should hold the pods until every job is admitted", func() {
+			By("creating gated jobs")
+			reconcileEnchantment(reconciler, key)
+			jobs := map[shared.Elemental]*batchv1.Job{}
+			Eventually(func(g Gomega) {
+				owned := ownedJobs(g, key)
+				g.Expect(owned).To(HaveLen(2))
+				for i := range owned {
+					jobs[shared.Elemental(owned[i].Labels[lblKeyEnergy])] = &owned[i]
+				}
+			}).Should(Succeed())
+			for _, job := range jobs {
+				Expect(job.Spec.Template.Spec.SchedulingGates).To(ConsistOf(corev1.PodSchedulingGate{Name: gangSchedulingGate}))
+			}
+
+			By("admitting only the fire job")
+			fire := jobs[shared.FireEnergy]
+			admit(fire)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fire.Name + "-pod",
+					Namespace: "default",
+					Labels:    fire.Spec.Template.Labels,
+				},
+				Spec: *fire.Spec.Template.Spec.DeepCopy(),
+			}
+			pod.Labels[batchv1.JobNameLabel] = fire.Name
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			Eventually(func() error {
+				return cachedClient.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})
+			}).Should(Succeed())
+
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.WaitingForGangAS))
+				g.Expect(ench.Status.Conditions).To(ContainElement(And(
+					HaveField("Type", enchantmentv1.ConditionAdmitted),
+					HaveField("Reason", enchantmentv1.ReasonGangIncomplete),
+				)))
+			}).Should(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+			Expect(pod.Spec.SchedulingGates).To(HaveLen(1), "the fire pod must wait for frost")
+
+			By("admitting the frost job")
+			admit(jobs[shared.FrostEnergy])
+			reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.EnchantingAS)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+			Expect(pod.Spec.SchedulingGates).To(BeEmpty())
+		})
+	})
+
+	Context("When a deadline passes", func() {
+		const resourceName = "deadline-bound"
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		create := func(mutate func(spec *enchantmentv1.EnchantmentSpec)) {
+			ench := newEnchantment(resourceName, 12)
+			mutate(&ench.Spec)
+			createEnchantment(ench)
+		}
+		expectTimedOut := func(reason string) {
+			Eventually(func(g Gomega) {
+				reconcileEnchantment(reconciler, key)
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+				failed := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+				g.Expect(failed).NotTo(BeNil())
+				g.Expect(failed.Reason).To(Equal(reason))
+				g.Expect(ench.Status.ExpiresAt).NotTo(BeNil())
+			}, 5*time.Second, 200*time.Millisecond).Should(Succeed())
+		}
+
+		AfterEach(func() {
+			Expect(k8sClient.DeleteAllOf(ctx, &kueue.Workload{}, client.InNamespace("default"))).To(Succeed())
+			removeEnchantments(resourceName)
+		})
+
+		It("should fail an Enchantment that is never admitted", func() {
+			timeout := 2
+			create(func(spec *enchantmentv1.EnchantmentSpec) { spec.QueueTimeoutSeconds = &timeout })
+
+			res := reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.ScheduledAS)
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(res.RequeueAfter).To(BeNumerically("<=", 2*time.Second))
+
+			expectTimedOut(enchantmentv1.ReasonQueueTimeout)
+		})
+
+		It("should stop the jobs of an Enchantment past its deadline", func() {
+			deadline := 2
+			create(func(spec *enchantmentv1.EnchantmentSpec) { spec.DeadlineSeconds = &deadline })
+
+			reconcileEnchantment(reconciler, key)
+			var job *batchv1.Job
+			Eventually(func(g Gomega) { job = ownedJob(g, key) }).Should(Succeed())
+
+			By("running the job")
+			startJob(job)
+
+			res := reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.EnchantingAS)
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0), "the deadline must wake the reconciler up")
+			Expect(res.RequeueAfter).To(BeNumerically("<=", 2*time.Second))
+
+			expectTimedOut(enchantmentv1.ReasonDeadlineExceeded)
+			Eventually(func(g Gomega) {
+				suspended := ownedJob(g, key).Spec.Suspend
+				g.Expect(suspended).NotTo(BeNil())
+				g.Expect(*suspended).To(BeTrue())
+			}).Should(Succeed())
+		})
+
+		It("should time out and expire on the clock of the reconciler", func() {
+			clock := &fakeClock{now: time.Now()}
+			reconciler.Clock = clock
+			deadline := 3600
+			create(func(spec *enchantmentv1.EnchantmentSpec) { spec.DeadlineSeconds = &deadline })
+
+			res := reconcileEnchantment(reconciler, key)
+			Expect(res.RequeueAfter).To(BeNumerically("~", time.Hour, 5*time.Second))
+
+			clock.now = clock.now.Add(2 * time.Hour)
+			Eventually(func(g Gomega) {
+				res = reconcileEnchantment(reconciler, key)
+				g.Expect(fetchEnchantment(g, key).Status.Phase).To(Equal(shared.FailedAS))
+			}).Should(Succeed())
+			Expect(res.RequeueAfter).To(BeNumerically("~", 60*time.Second, time.Second))
+			Expect(fetchEnchantment(Default, key).Status.ExpiresAt.Time).To(BeTemporally("~", clock.now.Add(60*time.Second), time.Second))
+		})
+
+		It("should not count the time spent Paused against the queue timeout", func() {
+			clock := &fakeClock{now: time.Now()}
+			reconciler.Clock = clock
+			timeout := 600
+			create(func(spec *enchantmentv1.EnchantmentSpec) { spec.QueueTimeoutSeconds = &timeout })
+			reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.ScheduledAS)
+
+			By("pausing for longer than the queue timeout")
+			patchSpec(key, func(spec *enchantmentv1.EnchantmentSpec) { spec.Suspend = true })
+			Eventually(func(g Gomega) {
+				reconcileEnchantment(reconciler, key)
+				g.Expect(fetchEnchantment(g, key).Status.Phase).To(Equal(shared.PausedAS))
+			}).Should(Succeed())
+			clock.now = clock.now.Add(time.Hour)
+
+			By("resuming")
+			patchSpec(key, func(spec *enchantmentv1.EnchantmentSpec) { spec.Suspend = false })
+			Eventually(func(g Gomega) {
+				reconcileEnchantment(reconciler, key)
+				g.Expect(fetchEnchantment(g, key).Status.Phase).To(Equal(shared.ScheduledAS))
+			}).Should(Succeed())
+			admitted := apimeta.FindStatusCondition(fetchEnchantment(Default, key).Status.Conditions, enchantmentv1.ConditionAdmitted)
+			Expect(admitted.LastTransitionTime.Time).To(BeTemporally("~", clock.now, time.Second))
+
+			res := reconcileEnchantment(reconciler, key)
+			Expect(fetchEnchantment(Default, key).Status.Phase).To(Equal(shared.ScheduledAS))
+			Expect(res.RequeueAfter).To(BeNumerically("~", 600*time.Second, 5*time.Second))
+		})
+
+		It("should deactivate the Workloads of an Enchantment past its deadline", func() {
+			reconciler.kueueEnabled = true
+			deadline := 2
+			create(func(spec *enchantmentv1.EnchantmentSpec) { spec.DeadlineSeconds = &deadline })
+
+			reconcileEnchantment(reconciler, key)
+			var job *batchv1.Job
+			Eventually(func(g Gomega) { job = ownedJob(g, key) }).Should(Succeed())
+
+			By("queueing the job with kueue")
+			wl := &kueue.Workload{
+				ObjectMeta: metav1.ObjectMeta{Name: "job-" + job.Name, Namespace: "default"},
+				Spec:       kueue.WorkloadSpec{QueueName: localKueue},
+			}
+			Expect(controllerutil.SetControllerReference(job, wl, cachedClient.Scheme())).To(Succeed())
+			Expect(k8sClient.Create(ctx, wl)).To(Succeed())
+			Eventually(func() error { return cachedClient.Get(ctx, client.ObjectKeyFromObject(wl), &kueue.Workload{}) }).Should(Succeed())
+
+			expectTimedOut(enchantmentv1.ReasonDeadlineExceeded)
+			Eventually(func(g Gomega) {
+				var cached kueue.Workload
+				g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(wl), &cached)).To(Succeed())
+				g.Expect(cached.Spec.Active).NotTo(BeNil())
+				g.Expect(*cached.Spec.Active).To(BeFalse(), "kueue would readmit the suspended job otherwise")
+			}).Should(Succeed())
+		})
+	})
+
+	Context("When paused, resumed and cancelled", func() {
+		const resourceName = "operated"
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			createEnchantment(newEnchantment(resourceName, 13))
+		})
+
+		AfterEach(func() {
+			removeEnchantments(resourceName)
+		})
+
+		It("should pause a running enchantment, resume it and cancel it", func() {
+			By("running the job")
+			reconcileEnchantment(reconciler, key)
+			var job *batchv1.Job
+			Eventually(func(g Gomega) { job = ownedJob(g, key) }).Should(Succeed())
+			startJob(job)
+			reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.EnchantingAS)
+
+			By("pausing")
+			patchSpec(key, func(spec *enchantmentv1.EnchantmentSpec) { spec.Suspend = true })
+			reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.PausedAS)
+			Eventually(func(g Gomega) { g.Expect(*ownedJob(g, key).Spec.Suspend).To(BeTrue()) }).Should(Succeed())
+
+			By("resuming")
+			patchSpec(key, func(spec *enchantmentv1.EnchantmentSpec) { spec.Suspend = false })
+			reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.RequeuedAS)
+
+			By("cancelling")
+			patchSpec(key, func(spec *enchantmentv1.EnchantmentSpec) { spec.Cancel = true })
+			reconcileEnchantment(reconciler, key)
+			expectEnchantmentPhase(key, shared.CancelledAS)
+			Eventually(func() bool {
+				return apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{}))
+			}).Should(BeTrue())
+			ench := &enchantmentv1.Enchantment{}
+			Expect(k8sClient.Get(ctx, key, ench)).To(Succeed())
+			Expect(ench.Status.ExpiresAt).NotTo(BeNil(), "history stays until the ttl")
+			Expect(ench.Status.Requirements).To(HaveLen(1))
+		})
+	})
+
+	Context("When built from a Recipe", func() {
+		const (
+			resourceName = "recipe-bound"
+			recipeName   = "frostfire-staff"
+		)
+
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			ench := newEnchantment(resourceName, 14)
+			ench.Spec.Artifact = enchantmentv1.EnchantmentSpecArtifact{}
+			ench.Spec.Recipe = recipeName
+			createEnchantment(ench)
+		})
+
+		AfterEach(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &enchantmentv1.Recipe{
+				ObjectMeta: metav1.ObjectMeta{Name: recipeName},
+			}))).To(Succeed())
+			removeEnchantments(resourceName)
+		})
+
+		It("should wait for a missing recipe", func() {
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Artifact).To(BeNil())
+				cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionJobsCreated)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonRecipeNotFound))
+			}).Should(Succeed())
+			Expect(ownedJobs(Default, key)).To(BeEmpty())
+		})
+
+		It("should pin the recipe and build the jobs from it", func() {
+			recipe := &enchantmentv1.Recipe{
+				ObjectMeta: metav1.ObjectMeta{Name: recipeName},
+				Spec: enchantmentv1.RecipeSpec{
+					ID:           2,
+					Name:         "Frostfire Staff",
+					Tier:         shared.Rare,
+					Requirements: enchantmentv1.RecipeRequirements{Fire: 2, Frost: 3},
+				},
+			}
+			createRecipe(recipe)
+
+			By("pinning the recipe")
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				artifact := fetchEnchantment(g, key).Status.Artifact
+				g.Expect(artifact).NotTo(BeNil())
+				g.Expect(artifact.Name).To(Equal("Frostfire Staff"))
+				g.Expect(artifact.Requirements).To(HaveLen(2))
+			}).Should(Succeed())
+
+			By("editing the recipe after it was pinned")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(recipe), recipe)).To(Succeed())
+			recipe.Spec.Requirements.Frost = 9
+			Expect(k8sClient.Update(ctx, recipe)).To(Succeed())
+			Eventually(func(g Gomega) {
+				var cached enchantmentv1.Recipe
+				g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(recipe), &cached)).To(Succeed())
+				g.Expect(cached.Spec.Requirements.Frost).To(Equal(9))
+			}).Should(Succeed())
+
+			By("creating the jobs")
+			reconcileEnchantment(reconciler, key)
+			limits := map[shared.Elemental]int64{}
+			Eventually(func(g Gomega) {
+				jobs := ownedJobs(g, key)
+				g.Expect(jobs).To(HaveLen(2))
+				for _, job := range jobs {
+					energy := shared.Elemental(job.Labels[lblKeyEnergy])
+					limit := job.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceName(energy.Resource())]
+					limits[energy] = limit.Value()
+				}
+			}).Should(Succeed())
+			Expect(limits[shared.FireEnergy]).To(Equal(int64(2)))
+			Expect(limits[shared.FrostEnergy]).To(Equal(int64(3)), "the pinned recipe wins over the edit")
+		})
+	})
+
+	Context("When it depends on other Enchantments", func() {
+		const (
+			bladeName = "dependent-blade"
+			coreName  = "dependency-core"
+			orderID   = 17
+			coreItem  = 7
+		)
+
+		bladeKey := types.NamespacedName{Name: bladeName, Namespace: "default"}
+		coreKey := types.NamespacedName{Name: coreName, Namespace: "default"}
+
+		createBlade := func(dep enchantmentv1.EnchantmentDependency) {
+			blade := newEnchantment(bladeName, orderID)
+			blade.Spec.DependsOn = []enchantmentv1.EnchantmentDependency{dep}
+			createEnchantment(blade)
+		}
+
+		BeforeEach(func() {
+			core := newEnchantment(coreName, orderID)
+			core.Spec.Artifact.ID = coreItem
+			createEnchantment(core)
+			setPhase(coreKey, shared.EnchantingAS)
+		})
+
+		AfterEach(func() {
+			removeEnchantments(bladeName, coreName)
+		})
+
+		It("should stay Blocked without jobs while a dependency runs", func() {
+			createBlade(enchantmentv1.EnchantmentDependency{Name: coreName})
+			reconcileEnchantment(reconciler, bladeKey)
+			expectEnchantmentPhase(bladeKey, shared.BlockedAS)
+
+			cond := apimeta.FindStatusCondition(fetchEnchantment(Default, bladeKey).Status.Conditions, enchantmentv1.ConditionDependenciesMet)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(enchantmentv1.ReasonDependenciesPending))
+			Expect(ownedJobs(Default, bladeKey)).To(BeEmpty())
+		})
+
+		It("should start once the dependency of an item completed", func() {
+			item := coreItem
+			createBlade(enchantmentv1.EnchantmentDependency{ItemID: &item})
+			reconcileEnchantment(reconciler, bladeKey)
+			expectEnchantmentPhase(bladeKey, shared.BlockedAS)
+
+			setPhase(coreKey, shared.CompletedAS)
+			reconcileEnchantment(reconciler, bladeKey)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, bladeKey)
+				g.Expect(ench.Status.Phase).To(Equal(shared.ScheduledAS))
+				g.Expect(apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchantmentv1.ConditionDependenciesMet)).To(BeTrue())
+			}).Should(Succeed())
+
+			// the next reconcile no longer looks at the dependency and creates the jobs
+			reconcileEnchantment(reconciler, bladeKey)
+			Eventually(func(g Gomega) { g.Expect(ownedJobs(g, bladeKey)).To(HaveLen(1)) }).Should(Succeed())
+		})
+
+		It("should fail once a dependency failed", func() {
+			createBlade(enchantmentv1.EnchantmentDependency{Name: coreName})
+			reconcileEnchantment(reconciler, bladeKey)
+			expectEnchantmentPhase(bladeKey, shared.BlockedAS)
+
+			setPhase(coreKey, shared.FailedAS)
+			reconcileEnchantment(reconciler, bladeKey)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, bladeKey)
+				g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+				g.Expect(ench.Status.ExpiresAt).NotTo(BeNil())
+				cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonDependencyFailed))
+			}).Should(Succeed())
+		})
+
+		It("should fail when the dependencies lead back to it", func() {
+			createBlade(enchantmentv1.EnchantmentDependency{Name: coreName})
+			patchSpec(coreKey, func(spec *enchantmentv1.EnchantmentSpec) {
+				spec.DependsOn = []enchantmentv1.EnchantmentDependency{{Name: bladeName}}
+			})
+			setPhase(coreKey, shared.BlockedAS)
+
+			reconcileEnchantment(reconciler, bladeKey)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, bladeKey)
+				g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+				cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonDependencyCycle))
+			}).Should(Succeed())
+		})
+
+		It("should fail once a dependency is still missing after the timeout", func() {
+			clock := &fakeClock{now: time.Now()}
+			reconciler.Clock = clock
+			reconciler.MissingDependencyTimeout = time.Hour
+			createBlade(enchantmentv1.EnchantmentDependency{Name: "never-created"})
+
+			res := reconcileEnchantment(reconciler, bladeKey)
+			expectEnchantmentPhase(bladeKey, shared.BlockedAS)
+			Expect(res.RequeueAfter).To(BeNumerically("~", time.Hour, 5*time.Second), "nothing else wakes a missing dependency up")
+
+			clock.now = clock.now.Add(2 * time.Hour)
+			Eventually(func(g Gomega) {
+				reconcileEnchantment(reconciler, bladeKey)
+				ench := fetchEnchantment(g, bladeKey)
+				g.Expect(ench.Status.Phase).To(Equal(shared.FailedAS))
+				cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionFailed)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonDependencyFailed))
+			}).Should(Succeed())
+		})
+
+		It("should keep an expired dependency while a dependent waits for it", func() {
+			createBlade(enchantmentv1.EnchantmentDependency{Name: coreName})
+			reconcileEnchantment(reconciler, bladeKey)
+			expectEnchantmentPhase(bladeKey, shared.BlockedAS)
+
+			blocked, err := reconciler.hasBlockedDependents(ctx, fetchEnchantment(Default, coreKey))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blocked).To(BeTrue())
+		})
+	})
+
+	Context("When ManaBudgets apply", func() {
+		const benchName = "budget-bench"
+
+		key := types.NamespacedName{Name: benchName, Namespace: "default"}
+		budgetKey := types.NamespacedName{Name: "bench-budget", Namespace: "default"}
+
+		createBudget := func(fire int, daily int64) {
+			budget := &enchantmentv1.ManaBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: budgetKey.Name, Namespace: budgetKey.Namespace},
+				Spec: enchantmentv1.ManaBudgetSpec{
+					Concurrent: enchantmentv1.ManaLimits{Fire: &fire},
+					DailySpend: &daily,
+				},
+			}
+			Expect(k8sClient.Create(ctx, budget)).To(Succeed())
+			Eventually(func() error { return cachedClient.Get(ctx, budgetKey, &enchantmentv1.ManaBudget{}) }).Should(Succeed())
+		}
+
+		AfterEach(func() {
+			removeEnchantments(benchName, "budget-running")
+			Expect(k8sClient.Delete(ctx, &enchantmentv1.ManaBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: budgetKey.Name, Namespace: budgetKey.Namespace},
+			})).To(Succeed())
+			Eventually(func() error { return cachedClient.Get(ctx, budgetKey, &enchantmentv1.ManaBudget{}) }).ShouldNot(Succeed())
+		})
+
+		It("should hold an Enchantment without jobs while the concurrent budget is used up", func() {
+			createBudget(4, 1000)
+			budgetEnchantment("budget-running", 3, true)
+			budgetEnchantment(benchName, 2, false)
+
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.QuotaExceededAS))
+				cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionReady)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonQuotaExceeded))
+				g.Expect(cond.Message).To(ContainSubstring("3 of 4 fire mana held"))
+			}).Should(Succeed())
+
+			By("creating the jobs once the running Enchantment is gone")
+			removeEnchantments("budget-running")
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).NotTo(Equal(shared.QuotaExceededAS))
+				g.Expect(apimeta.IsStatusConditionTrue(ench.Status.Conditions, enchantmentv1.ConditionJobsCreated)).To(BeTrue())
+			}).Should(Succeed())
+		})
+
+		It("should hold an Enchantment once the daily spend is used up", func() {
+			createBudget(10, 3)
+			budgetEnchantment(benchName, 2, false)
+
+			reconcileEnchantment(reconciler, key)
+			Eventually(func(g Gomega) {
+				ench := fetchEnchantment(g, key)
+				g.Expect(ench.Status.Phase).To(Equal(shared.QuotaExceededAS))
+				cond := apimeta.FindStatusCondition(ench.Status.Conditions, enchantmentv1.ConditionJobsCreated)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Message).To(ContainSubstring("0 of 3 daily mana spent"))
+			}).Should(Succeed())
+		})
+
+		It("should charge the spend of a completed Enchantment", func() {
+			createBudget(10, 1000)
+			ench := budgetEnchantment(benchName, 2, false)
+
+			Expect(reconciler.chargeBudgets(ctx, ench)).To(Succeed())
+			Expect(reconciler.chargeBudgets(ctx, ench)).To(Succeed())
+			Eventually(func(g Gomega) {
+				var budget enchantmentv1.ManaBudget
+				g.Expect(cachedClient.Get(ctx, budgetKey, &budget)).To(Succeed())
+				g.Expect(budget.Status.Spend).To(HaveLen(1))
+				g.Expect(budget.Status.SpentToday).To(Equal(int64(8)))
+			}).Should(Succeed())
+		})
+
+		It("should charge a completed Enchantment once although a stale copy is reconciled again", func() {
+			createBudget(10, 1000)
+			budgetEnchantment(benchName, 2, false)
+			Eventually(func(g Gomega) {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(apimeta.IsStatusConditionTrue(fetchEnchantment(g, key).Status.Conditions, enchantmentv1.ConditionJobsCreated)).To(BeTrue())
+			}).Should(Succeed())
+
+			var job *batchv1.Job
+			Eventually(func(g Gomega) { job = ownedJob(g, key) }).Should(Succeed())
+			startJob(job)
+			job.Status.Active = 0
+			job.Status.Succeeded = 1
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+			Eventually(func(g Gomega) { g.Expect(ownedJob(g, key).Status.Succeeded).To(Equal(int32(1))) }).Should(Succeed())
+
+			var stale, completed *enchantmentv1.Enchantment
+			Eventually(func(g Gomega) { stale = fetchEnchantment(g, key) }).Should(Succeed())
+			_, err := reconciler.reconcilePhase(ctx, stale.DeepCopy(), shared.EnchantingAS, &ptrStatus{namespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func(g Gomega) {
+				completed = fetchEnchantment(g, key)
+				g.Expect(completed.Status.Phase).To(Equal(shared.CompletedAS))
+				cond := apimeta.FindStatusCondition(completed.Status.Conditions, enchantmentv1.ConditionBudgetCharged)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			}).Should(Succeed())
+
+			By("charging on the next reconcile")
+			Eventually(func(g Gomega) {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(apimeta.IsStatusConditionTrue(fetchEnchantment(g, key).Status.Conditions, enchantmentv1.ConditionBudgetCharged)).To(BeTrue())
+			}).Should(Succeed())
+
+			By("reconciling the copies from before the charge")
+			_, err = reconciler.reconcilePhase(ctx, stale.DeepCopy(), shared.EnchantingAS, &ptrStatus{namespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			err = reconciler.chargeOnce(ctx, completed.DeepCopy())
+			Expect(apierrors.IsConflict(err)).To(BeTrue(), "a stale copy must not claim the charge, got %v", err)
+			Consistently(func(g Gomega) {
+				var budget enchantmentv1.ManaBudget
+				g.Expect(cachedClient.Get(ctx, budgetKey, &budget)).To(Succeed())
+				g.Expect(budget.Status.SpentToday).To(Equal(int64(4)))
+			}, "2s").Should(Succeed())
+		})
+
+		It("should count an admitted Enchantment before its jobs show up", func() {
+			createBudget(4, 1000)
+			budgetEnchantment("budget-running", 3, false)
+			bench := budgetEnchantment(benchName, 2, false)
+
+			running := fetchEnchantment(Default, types.NamespacedName{Name: "budget-running", Namespace: "default"})
+			var budgets enchantmentv1.ManaBudgetList
+			Expect(cachedClient.List(ctx, &budgets, client.InNamespace("default"))).To(Succeed())
+			_, held, err := reconciler.enforceBudgets(ctx, running, running.Status.Phase,
+				&ptrStatus{namespacedName: client.ObjectKeyFromObject(running)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(held).To(BeFalse())
+
+			msg, _, err := reconciler.overBudget(ctx, bench, budgets.Items)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msg).To(ContainSubstring("3 of 4 fire mana held"))
+
+			By("keeping it while another namespace is checked")
+			Expect(reconciler.reserved.admitted("elsewhere", nil, "")).To(BeEmpty())
+			msg, _, err = reconciler.overBudget(ctx, bench, budgets.Items)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msg).To(ContainSubstring("3 of 4 fire mana held"))
+
+			By("dropping the reservation once the admitted Enchantment is gone")
+			removeEnchantments("budget-running")
+			msg, _, err = reconciler.overBudget(ctx, bench, budgets.Items)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msg).To(BeEmpty())
+			Expect(reconciler.reserved.byNamespace).To(BeEmpty())
+		})
+
+		It("should not reserve in a namespace without ManaBudgets", func() {
+			createBudget(10, 1000)
+			ench := budgetEnchantment(benchName, 2, false)
+			Expect(k8sClient.Delete(ctx, &enchantmentv1.ManaBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: budgetKey.Name, Namespace: budgetKey.Namespace},
+			})).To(Succeed())
+			Eventually(func() error { return cachedClient.Get(ctx, budgetKey, &enchantmentv1.ManaBudget{}) }).ShouldNot(Succeed())
+
+			_, held, err := reconciler.enforceBudgets(ctx, ench, shared.ScheduledAS, &ptrStatus{namespacedName: key})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			Expect(held).To(BeFalse())
+			Expect(reconciler.reserved.byNamespace).To(BeEmpty())
+			createBudget(10, 1000) // for the AfterEach
 		})
 	})
 })
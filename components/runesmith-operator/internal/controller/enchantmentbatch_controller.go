@@ -0,0 +1,335 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+
+	"github.com/fukaraca/runesmith/shared"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+const (
+	lblKeyBatch      = "enchantment.runesmith.io/batch"
+	annKeyBatchIndex = "enchantment.runesmith.io/batch-index"
+	batchOwnerIndex  = "batchIndex"
+)
+
+// EnchantmentBatchReconciler reconciles a EnchantmentBatch object
+type EnchantmentBatchReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantmentbatches,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantmentbatches/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantmentbatches/finalizers,verbs=update
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=recipes,verbs=get;list;watch
+
+// Reconcile fans the batch out into child Enchantments the way a Job manages its pods. Children are named
+// after their index so a stale cache can't create one twice, and no more than spec.parallelism of them are
+// unfinished at a time. Finished children are recorded by index in the status, they don't expire and go
+// away with the batch.
+func (r *EnchantmentBatchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	batch := &enchv1.EnchantmentBatch{}
+	if err := r.Get(ctx, req.NamespacedName, batch); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("enchantment batch resource not found. ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "failed to get EnchantmentBatch")
+		return ctrl.Result{}, err
+	}
+	if !batch.DeletionTimestamp.IsZero() || batch.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	var children enchv1.EnchantmentList
+	if err := r.List(ctx, &children,
+		client.InNamespace(batch.Namespace),
+		client.MatchingFields{batchOwnerIndex: string(batch.UID)},
+	); err != nil {
+		logger.Error(err, "failed to list child Enchantments")
+		return ctrl.Result{}, err
+	}
+
+	status := batch.Status.DeepCopy()
+	succeeded := indexSet(status.SucceededIndexes)
+	failed := indexSet(status.FailedIndexes)
+	status.Active = 0
+	taken := make(map[int]bool, len(children.Items))
+	for i := range children.Items {
+		child := &children.Items[i]
+		idx, err := strconv.Atoi(child.Annotations[annKeyBatchIndex])
+		if err != nil {
+			continue // not forged by the batch
+		}
+		taken[idx] = true
+		switch {
+		case succeeded[idx] || failed[idx]:
+		case child.Status.Phase == shared.CompletedAS:
+			succeeded[idx] = true
+		case child.Status.Phase == shared.FailedAS || child.Status.Phase == shared.CancelledAS:
+			failed[idx] = true
+		default:
+			// children being deleted still hold their slot until they are gone
+			status.Active++
+		}
+	}
+	// a finished child that is gone keeps its index
+	for idx := range succeeded {
+		taken[idx] = true
+	}
+	for idx := range failed {
+		taken[idx] = true
+	}
+	status.SucceededIndexes, status.Succeeded = sortedIndexes(succeeded), len(succeeded)
+	status.FailedIndexes, status.Failed = sortedIndexes(failed), len(failed)
+
+	if status.StartTime == nil {
+		now := metav1.Now()
+		status.StartTime = &now
+	}
+	status.ObservedGeneration = batch.Generation
+	status.Phase = enchv1.BatchRunning
+
+	switch {
+	case status.Succeeded+status.Failed >= batch.Spec.Count:
+		now := metav1.Now()
+		status.CompletionTime = &now
+		status.Phase = enchv1.BatchCompleted
+		condType, ready, reason := enchv1.ConditionSucceeded, metav1.ConditionTrue, enchv1.ReasonChildrenSucceeded
+		if status.Failed > 0 {
+			status.Phase = enchv1.BatchFailed
+			condType, ready, reason = enchv1.ConditionFailed, metav1.ConditionFalse, enchv1.ReasonChildFailed
+		}
+		msg := fmt.Sprintf("%d succeeded, %d failed of %d", status.Succeeded, status.Failed, batch.Spec.Count)
+		setBatchCondition(status, condType, metav1.ConditionTrue, reason, msg)
+		setBatchCondition(status, enchv1.ConditionProgressing, metav1.ConditionFalse, reason, msg)
+		setBatchCondition(status, enchv1.ConditionReady, ready, reason, msg)
+	default:
+		if err := r.createChildren(ctx, batch, taken, status); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if equality.Semantic.DeepEqual(&batch.Status, status) {
+		return ctrl.Result{}, nil
+	}
+	patch := client.MergeFrom(batch.DeepCopy())
+	batch.Status = *status
+	if err := r.Status().Patch(ctx, batch, patch); err != nil {
+		logger.Error(err, "failed to update EnchantmentBatch status")
+		return ctrl.Result{}, err
+	}
+	if status.CompletionTime != nil {
+		eventType := corev1.EventTypeNormal
+		if status.Phase == enchv1.BatchFailed {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Eventf(batch, eventType, string(status.Phase), "%d succeeded, %d failed", status.Succeeded, status.Failed)
+	}
+	return ctrl.Result{}, nil
+}
+
+// createChildren creates the missing children with the lowest indexes until parallelism is reached
+func (r *EnchantmentBatchReconciler) createChildren(ctx context.Context, batch *enchv1.EnchantmentBatch,
+	taken map[int]bool, status *enchv1.EnchantmentBatchStatus) error {
+	var recipes []enchv1.Recipe
+	for idx := 0; idx < batch.Spec.Count && status.Active < batch.Spec.Parallelism; idx++ {
+		if taken[idx] {
+			continue
+		}
+		if recipes == nil {
+			var err error
+			if recipes, err = matchingRecipes(ctx, r.Client, batch.Spec.Selector); err != nil {
+				return err
+			}
+			if len(recipes) == 0 {
+				// the Recipe watch brings the batch back once the catalog has a match
+				msg := "no Recipe matches the selector"
+				r.Recorder.Event(batch, corev1.EventTypeWarning, enchv1.ReasonNoMatchingRecipe, msg)
+				setBatchCondition(status, enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonNoMatchingRecipe, msg)
+				setBatchCondition(status, enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonNoMatchingRecipe, msg)
+				return nil
+			}
+		}
+		child, err := r.newChild(batch, idx, &recipes[rand.Intn(len(recipes))])
+		if err != nil {
+			return err
+		}
+		if err = r.Create(ctx, child); err != nil && !errors.IsAlreadyExists(err) {
+			log.FromContext(ctx).Error(err, "failed to create child Enchantment", "index", idx)
+			return err
+		}
+		status.Active++
+	}
+
+	msg := fmt.Sprintf("%d active, %d/%d finished", status.Active, status.Succeeded+status.Failed, batch.Spec.Count)
+	setBatchCondition(status, enchv1.ConditionProgressing, metav1.ConditionTrue, enchv1.ReasonChildrenRunning, msg)
+	setBatchCondition(status, enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonChildrenRunning, msg)
+	return nil
+}
+
+func (r *EnchantmentBatchReconciler) newChild(batch *enchv1.EnchantmentBatch, idx int, recipe *enchv1.Recipe) (*enchv1.Enchantment, error) {
+	child := &enchv1.Enchantment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%d", batch.Name, idx),
+			Namespace:   batch.Namespace,
+			Labels:      map[string]string{lblKeyBatch: batch.Name},
+			Annotations: map[string]string{annKeyBatchIndex: strconv.Itoa(idx)},
+		},
+		Spec: templateSpec(batch.Spec.OrderID, &batch.Spec.Template, recipe),
+	}
+	if err := controllerutil.SetControllerReference(batch, child, r.Scheme); err != nil {
+		return nil, err
+	}
+	return child, nil
+}
+
+func indexSet(indexes []int) map[int]bool {
+	set := make(map[int]bool, len(indexes))
+	for _, idx := range indexes {
+		set[idx] = true
+	}
+	return set
+}
+
+func sortedIndexes(set map[int]bool) []int {
+	if len(set) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(set))
+	for idx := range set {
+		indexes = append(indexes, idx)
+	}
+	slices.Sort(indexes)
+	return indexes
+}
+
+// templateSpec is the spec of an Enchantment forged from the Recipe by a batch or a schedule. Its retention
+// is left to the parent, see keptByParent.
+func templateSpec(orderID int, tmpl *enchv1.EnchantmentBatchTemplate, recipe *enchv1.Recipe) enchv1.EnchantmentSpec {
+	return enchv1.EnchantmentSpec{
+		OrderID:        orderID,
+		Recipe:         recipe.Name,
+		Cost:           tmpl.Cost,
+		RetryPolicy:    tmpl.RetryPolicy.DeepCopy(),
+		Profile:        tmpl.Profile,
+		GangScheduling: tmpl.GangScheduling,
+	}
+}
+
+func setBatchCondition(status *enchv1.EnchantmentBatchStatus, condType string, s metav1.ConditionStatus, reason, message string) {
+	apimeta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             s,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: status.ObservedGeneration,
+	})
+}
+
+// batchesForRecipe wakes up the batches stuck on a selector nothing matched
+func (r *EnchantmentBatchReconciler) batchesForRecipe(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list enchv1.EnchantmentBatchList
+	if err := r.List(ctx, &list); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list enchantment batches for recipe", "recipe", obj.GetName())
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, batch := range list.Items {
+		ready := apimeta.FindStatusCondition(batch.Status.Conditions, enchv1.ConditionReady)
+		if ready == nil || ready.Reason != enchv1.ReasonNoMatchingRecipe {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&batch)})
+	}
+	return reqs
+}
+
+// childPhaseChangedPredicate passes the child events that can move the counts of a batch
+func childPhaseChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldEnch, ok := e.ObjectOld.(*enchv1.Enchantment)
+			if !ok {
+				return true
+			}
+			newEnch, ok := e.ObjectNew.(*enchv1.Enchantment)
+			if !ok {
+				return true
+			}
+			return oldEnch.Status.Phase != newEnch.Status.Phase
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// SetupIndexes registers the cache indexes the reconciler lists by.
+func (r *EnchantmentBatchReconciler) SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx,
+		&enchv1.Enchantment{}, batchOwnerIndex,
+		func(obj client.Object) []string {
+			if owner := metav1.GetControllerOf(obj); owner != nil &&
+				owner.APIVersion == enchv1.GroupVersion.String() &&
+				owner.Kind == "EnchantmentBatch" {
+				return []string{string(owner.UID)}
+			}
+			return nil
+		})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EnchantmentBatchReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.SetupIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+	r.Recorder = mgr.GetEventRecorderFor("runesmith-operator")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&enchv1.EnchantmentBatch{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&enchv1.Enchantment{}, builder.WithPredicates(childPhaseChangedPredicate())).
+		Watches(&enchv1.Recipe{}, handler.EnqueueRequestsFromMapFunc(r.batchesForRecipe)).
+		Named("enchantmentbatch").
+		Complete(r)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/fukaraca/runesmith/shared"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+var _ = Describe("EnchantmentBatch Controller", func() {
+	const resourceName = "common-batch"
+
+	key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+	var reconciler *EnchantmentBatchReconciler
+
+	fetch := func(g Gomega) *enchantmentv1.EnchantmentBatch {
+		var batch enchantmentv1.EnchantmentBatch
+		g.Expect(cachedClient.Get(ctx, key, &batch)).To(Succeed())
+		return &batch
+	}
+	reconcileOnce := func() {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+	}
+	children := func(g Gomega) map[string]*enchantmentv1.Enchantment {
+		return childEnchantments(g, batchOwnerIndex, fetch(g).UID)
+	}
+	// finish sets the phase the Enchantment controller would report and waits for the cache to see it
+	finish := func(name string, phase shared.EnchantmentPhase) {
+		ench := &enchantmentv1.Enchantment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, ench)).To(Succeed())
+		ench.Status.Phase = phase
+		Expect(k8sClient.Status().Update(ctx, ench)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(children(g)[name].Status.Phase).To(Equal(phase))
+		}).Should(Succeed())
+	}
+	create := func(spec enchantmentv1.EnchantmentBatchSpec) {
+		batch := &enchantmentv1.EnchantmentBatch{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec:       spec,
+		}
+		Expect(k8sClient.Create(ctx, batch)).To(Succeed())
+		Eventually(func() error { return cachedClient.Get(ctx, key, &enchantmentv1.EnchantmentBatch{}) }).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		for _, recipe := range []enchantmentv1.Recipe{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ember-blade"},
+				Spec: enchantmentv1.RecipeSpec{ID: 1, Name: "Ember Blade", Tier: shared.Common,
+					Requirements: enchantmentv1.RecipeRequirements{Fire: 2}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "frost-shard"},
+				Spec: enchantmentv1.RecipeSpec{ID: 2, Name: "Frost Shard", Tier: shared.Common,
+					Requirements: enchantmentv1.RecipeRequirements{Frost: 2}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "blizzard-staff"},
+				Spec: enchantmentv1.RecipeSpec{ID: 12, Name: "Blizzard Staff", Tier: shared.Rare,
+					Requirements: enchantmentv1.RecipeRequirements{Frost: 3, Arcane: 3}},
+			},
+		} {
+			Expect(k8sClient.Create(ctx, &recipe)).To(Succeed())
+		}
+		Eventually(func(g Gomega) {
+			var list enchantmentv1.RecipeList
+			g.Expect(cachedClient.List(ctx, &list)).To(Succeed())
+			g.Expect(list.Items).To(HaveLen(3))
+		}).Should(Succeed())
+
+		reconciler = &EnchantmentBatchReconciler{
+			Client:   cachedClient,
+			Scheme:   cachedClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &enchantmentv1.Enchantment{}, client.InNamespace("default"),
+			client.HasLabels{lblKeyBatch})).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &enchantmentv1.Recipe{})).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &enchantmentv1.EnchantmentBatch{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		})).To(Succeed())
+		Eventually(func() error {
+			return cachedClient.Get(ctx, key, &enchantmentv1.EnchantmentBatch{})
+		}).ShouldNot(Succeed())
+	})
+
+	It("should fan out within parallelism and aggregate the results", func() {
+		create(enchantmentv1.EnchantmentBatchSpec{
+			OrderID:     15,
+			Count:       3,
+			Parallelism: 2,
+			Selector:    enchantmentv1.EnchantmentBatchSelector{Tier: shared.Common},
+			Template:    enchantmentv1.EnchantmentBatchTemplate{Cost: 1},
+		})
+
+		By("creating the first children")
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			kids := children(g)
+			g.Expect(kids).To(HaveLen(2))
+			g.Expect(kids).To(HaveKey(resourceName + "-0"))
+			g.Expect(kids).To(HaveKey(resourceName + "-1"))
+			for _, child := range kids {
+				g.Expect(child.Spec.Recipe).To(BeElementOf("ember-blade", "frost-shard"))
+				g.Expect(child.Spec.OrderID).To(Equal(15))
+				g.Expect(child.Labels).To(HaveKeyWithValue(lblKeyBatch, resourceName))
+			}
+			g.Expect(fetch(g).Status.Active).To(Equal(2))
+		}).Should(Succeed())
+
+		By("replacing a finished child with the next index")
+		finish(resourceName+"-0", shared.CompletedAS)
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			g.Expect(children(g)).To(HaveKey(resourceName + "-2"))
+			status := fetch(g).Status
+			g.Expect(status.Phase).To(Equal(enchantmentv1.BatchRunning))
+			g.Expect(status.Succeeded).To(Equal(1))
+			g.Expect(status.Active).To(Equal(2))
+		}).Should(Succeed())
+
+		By("finishing the rest")
+		finish(resourceName+"-1", shared.FailedAS)
+		finish(resourceName+"-2", shared.CompletedAS)
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			status := fetch(g).Status
+			g.Expect(status.Phase).To(Equal(enchantmentv1.BatchFailed))
+			g.Expect(status.Succeeded).To(Equal(2))
+			g.Expect(status.Failed).To(Equal(1))
+			g.Expect(status.Active).To(BeZero())
+			g.Expect(status.CompletionTime).NotTo(BeNil())
+			g.Expect(apimeta.IsStatusConditionTrue(status.Conditions, enchantmentv1.ConditionFailed)).To(BeTrue())
+		}).Should(Succeed())
+		Expect(children(Default)).To(HaveLen(3))
+	})
+
+	It("should not forge a finished child again once it is gone", func() {
+		create(enchantmentv1.EnchantmentBatchSpec{
+			OrderID:     15,
+			Count:       3,
+			Parallelism: 1,
+			Selector:    enchantmentv1.EnchantmentBatchSelector{Tier: shared.Common},
+			Template:    enchantmentv1.EnchantmentBatchTemplate{Cost: 1},
+		})
+		reconcileOnce()
+		Eventually(func(g Gomega) { g.Expect(children(g)).To(HaveKey(resourceName + "-0")) }).Should(Succeed())
+		Expect(keptByParent(children(Default)[resourceName+"-0"])).To(BeTrue(), "a child must not expire on its own")
+
+		finish(resourceName+"-0", shared.CompletedAS)
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			g.Expect(children(g)).To(HaveKey(resourceName + "-1"))
+			g.Expect(fetch(g).Status.SucceededIndexes).To(ConsistOf(0))
+		}).Should(Succeed())
+
+		By("deleting the finished child")
+		Expect(k8sClient.Delete(ctx, &enchantmentv1.Enchantment{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName + "-0", Namespace: "default"},
+		})).To(Succeed())
+		Eventually(func(g Gomega) { g.Expect(children(g)).NotTo(HaveKey(resourceName + "-0")) }).Should(Succeed())
+
+		finish(resourceName+"-1", shared.CompletedAS)
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			kids := children(g)
+			g.Expect(kids).To(HaveKey(resourceName + "-2"))
+			g.Expect(kids).NotTo(HaveKey(resourceName + "-0"))
+			status := fetch(g).Status
+			g.Expect(status.Succeeded).To(Equal(2))
+			g.Expect(status.Active).To(Equal(1))
+		}).Should(Succeed())
+	})
+
+	It("should wait for a recipe matching the selector", func() {
+		itemID := 99
+		create(enchantmentv1.EnchantmentBatchSpec{
+			OrderID:     15,
+			Count:       2,
+			Parallelism: 2,
+			Selector:    enchantmentv1.EnchantmentBatchSelector{ItemID: &itemID},
+			Template:    enchantmentv1.EnchantmentBatchTemplate{Cost: 1},
+		})
+
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			ready := apimeta.FindStatusCondition(fetch(g).Status.Conditions, enchantmentv1.ConditionReady)
+			g.Expect(ready).NotTo(BeNil())
+			g.Expect(ready.Reason).To(Equal(enchantmentv1.ReasonNoMatchingRecipe))
+		}).Should(Succeed())
+		Expect(children(Default)).To(BeEmpty())
+	})
+})
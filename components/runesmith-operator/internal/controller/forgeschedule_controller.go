@@ -0,0 +1,364 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ref "k8s.io/client-go/tools/reference"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+const (
+	lblKeySchedule     = "enchantment.runesmith.io/schedule"
+	annKeyScheduledAt  = "enchantment.runesmith.io/scheduled-at"
+	scheduleOwnerIndex = "scheduleIndex"
+
+	// maxMissedRuns is how many missed runs are tolerated before the schedule reports them, only the
+	// latest missed run is started either way
+	maxMissedRuns = 100
+)
+
+// ForgeScheduleReconciler reconciles a ForgeSchedule object
+type ForgeScheduleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Clock defaults to the wall clock
+	Clock Clock
+}
+
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=forgeschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=forgeschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=forgeschedules/finalizers,verbs=update
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=recipes,verbs=get;list;watch
+
+// Reconcile creates the Enchantment of the latest due run the way a CronJob creates Jobs, prunes the
+// finished ones beyond the history limits and requeues itself for the next run.
+func (r *ForgeScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	fs := &enchv1.ForgeSchedule{}
+	if err := r.Get(ctx, req.NamespacedName, fs); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("forge schedule resource not found. ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "failed to get ForgeSchedule")
+		return ctrl.Result{}, err
+	}
+	if !fs.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	var children enchv1.EnchantmentList
+	if err := r.List(ctx, &children,
+		client.InNamespace(fs.Namespace),
+		client.MatchingFields{scheduleOwnerIndex: string(fs.UID)},
+	); err != nil {
+		logger.Error(err, "failed to list child Enchantments")
+		return ctrl.Result{}, err
+	}
+
+	status := fs.Status.DeepCopy()
+	status.Active = nil
+	var succeeded, failed []*enchv1.Enchantment
+	for i := range children.Items {
+		child := &children.Items[i]
+		switch child.Status.Phase {
+		case shared.CompletedAS:
+			succeeded = append(succeeded, child)
+			if ct := child.Status.CompletionTime; ct != nil &&
+				(status.LastSuccessfulTime == nil || status.LastSuccessfulTime.Before(ct)) {
+				status.LastSuccessfulTime = ct
+			}
+		case shared.FailedAS, shared.CancelledAS:
+			failed = append(failed, child)
+		default:
+			if !child.DeletionTimestamp.IsZero() {
+				continue
+			}
+			childRef, err := ref.GetReference(r.Scheme, child)
+			if err != nil {
+				logger.Error(err, "unable to make reference to active Enchantment", "enchantment", child.Name)
+				continue
+			}
+			status.Active = append(status.Active, *childRef)
+		}
+		if at := scheduledAt(child); at != nil && (status.LastScheduleTime == nil || status.LastScheduleTime.Before(at)) {
+			status.LastScheduleTime = at
+		}
+	}
+	sort.Slice(status.Active, func(i, j int) bool { return status.Active[i].Name < status.Active[j].Name })
+	r.pruneHistory(ctx, succeeded, fs.Spec.SuccessfulHistoryLimit)
+	r.pruneHistory(ctx, failed, fs.Spec.FailedHistoryLimit)
+
+	res, err := r.run(ctx, fs, status)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !equality.Semantic.DeepEqual(&fs.Status, status) {
+		patch := client.MergeFrom(fs.DeepCopy())
+		fs.Status = *status
+		if err = r.Status().Patch(ctx, fs, patch); err != nil {
+			logger.Error(err, "failed to update ForgeSchedule status")
+			return ctrl.Result{}, err
+		}
+	}
+	return res, nil
+}
+
+// run starts the latest due run if the policies allow it and tells when the next one is due
+func (r *ForgeScheduleReconciler) run(ctx context.Context, fs *enchv1.ForgeSchedule, status *enchv1.ForgeScheduleStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if fs.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+
+	sched, err := cron.ParseStandard(fs.Spec.Schedule)
+	if err != nil {
+		// a spec change brings us back
+		msg := fmt.Sprintf("unparseable schedule %q: %v", fs.Spec.Schedule, err)
+		r.Recorder.Event(fs, corev1.EventTypeWarning, enchv1.ReasonInvalidSchedule, msg)
+		setScheduleCondition(status, metav1.ConditionFalse, enchv1.ReasonInvalidSchedule, msg)
+		return ctrl.Result{}, nil
+	}
+
+	now := r.now()
+	missedRun, missed, next := nextRuns(fs, status, sched, now)
+	res := ctrl.Result{RequeueAfter: next.Sub(now)}
+	if missedRun.IsZero() {
+		return res, nil
+	}
+	if missed > maxMissedRuns {
+		r.Recorder.Eventf(fs, corev1.EventTypeWarning, enchv1.ReasonMissedRuns,
+			"missed more than %d runs, check the clock skew or startingDeadlineSeconds", maxMissedRuns)
+	}
+	// the run counts as scheduled whether it starts or is skipped, so it isn't retried forever
+	lastSchedule := metav1.NewTime(missedRun)
+	status.LastScheduleTime = &lastSchedule
+
+	if d := fs.Spec.StartingDeadlineSeconds; d != nil && missedRun.Add(time.Duration(*d)*time.Second).Before(now) {
+		msg := fmt.Sprintf("run of %s missed its starting deadline", missedRun.Format(time.RFC3339))
+		r.Recorder.Event(fs, corev1.EventTypeWarning, enchv1.ReasonRunSkipped, msg)
+		setScheduleCondition(status, metav1.ConditionTrue, enchv1.ReasonRunSkipped, msg)
+		return res, nil
+	}
+	switch fs.Spec.ConcurrencyPolicy {
+	case enchv1.ForbidConcurrent:
+		if len(status.Active) > 0 {
+			msg := fmt.Sprintf("run of %s skipped, %d Enchantments are still active", missedRun.Format(time.RFC3339), len(status.Active))
+			r.Recorder.Event(fs, corev1.EventTypeNormal, enchv1.ReasonRunSkipped, msg)
+			setScheduleCondition(status, metav1.ConditionTrue, enchv1.ReasonRunSkipped, msg)
+			return res, nil
+		}
+	case enchv1.ReplaceConcurrent:
+		for _, active := range status.Active {
+			ench := &enchv1.Enchantment{ObjectMeta: metav1.ObjectMeta{Name: active.Name, Namespace: active.Namespace}}
+			if err = r.Delete(ctx, ench, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "unable to delete active Enchantment", "enchantment", active.Name)
+				return ctrl.Result{}, err
+			}
+		}
+		status.Active = nil
+	}
+
+	recipes, err := matchingRecipes(ctx, r.Client, fs.Spec.Selector)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(recipes) == 0 {
+		msg := fmt.Sprintf("run of %s skipped, no Recipe matches the selector", missedRun.Format(time.RFC3339))
+		r.Recorder.Event(fs, corev1.EventTypeWarning, enchv1.ReasonNoMatchingRecipe, msg)
+		setScheduleCondition(status, metav1.ConditionFalse, enchv1.ReasonNoMatchingRecipe, msg)
+		return res, nil
+	}
+
+	child, err := r.newChild(fs, missedRun, &recipes[rand.Intn(len(recipes))])
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = r.Create(ctx, child); err != nil && !errors.IsAlreadyExists(err) {
+		logger.Error(err, "failed to create Enchantment for run", "run", missedRun)
+		return ctrl.Result{}, err
+	}
+	childRef, err := ref.GetReference(r.Scheme, child)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	status.Active = append(status.Active, *childRef)
+	r.Recorder.Eventf(fs, corev1.EventTypeNormal, enchv1.ReasonScheduled, "created Enchantment %s", child.Name)
+	setScheduleCondition(status, metav1.ConditionTrue, enchv1.ReasonScheduled,
+		fmt.Sprintf("next run at %s", next.Format(time.RFC3339)))
+	return res, nil
+}
+
+// nextRuns returns the latest run that is due but not scheduled yet, how many were missed and when the next
+// one is due. Runs older than the starting deadline aren't counted, and like CronJob the counting stops past
+// maxMissedRuns so that a schedule that was off for long doesn't walk every run it missed.
+func nextRuns(fs *enchv1.ForgeSchedule, status *enchv1.ForgeScheduleStatus, sched cron.Schedule, now time.Time) (time.Time, int, time.Time) {
+	earliest := fs.CreationTimestamp.Time
+	if status.LastScheduleTime != nil {
+		earliest = status.LastScheduleTime.Time
+	}
+	if d := fs.Spec.StartingDeadlineSeconds; d != nil {
+		if limit := now.Add(-time.Duration(*d) * time.Second); limit.After(earliest) {
+			earliest = limit
+		}
+	}
+	var last time.Time
+	missed := 0
+	for t := sched.Next(earliest); !t.After(now); t = sched.Next(t) {
+		if missed++; missed > maxMissedRuns {
+			return latestRun(sched, last, now), missed, sched.Next(now)
+		}
+		last = t
+	}
+	return last, missed, sched.Next(now)
+}
+
+// latestRun returns the latest run after the given one that is due by now. It looks back from now over a
+// window that doubles until the window holds a run, instead of walking forward from the given run.
+func latestRun(sched cron.Schedule, after, now time.Time) time.Time {
+	for window := time.Minute; ; window *= 2 {
+		from := now.Add(-window)
+		if from.Before(after) {
+			from = after
+		}
+		last := after
+		for t := sched.Next(from); !t.After(now); t = sched.Next(t) {
+			last = t
+		}
+		if last.After(after) || from.Equal(after) {
+			return last
+		}
+	}
+}
+
+func (r *ForgeScheduleReconciler) newChild(fs *enchv1.ForgeSchedule, run time.Time, recipe *enchv1.Recipe) (*enchv1.Enchantment, error) {
+	child := &enchv1.Enchantment{
+		ObjectMeta: metav1.ObjectMeta{
+			// named after the run so that a stale cache can't create it twice
+			Name:        fmt.Sprintf("%s-%d", fs.Name, run.Unix()),
+			Namespace:   fs.Namespace,
+			Labels:      map[string]string{lblKeySchedule: fs.Name},
+			Annotations: map[string]string{annKeyScheduledAt: run.Format(time.RFC3339)},
+		},
+		Spec: templateSpec(fs.Spec.OrderID, &fs.Spec.Template, recipe),
+	}
+	if err := controllerutil.SetControllerReference(fs, child, r.Scheme); err != nil {
+		return nil, err
+	}
+	return child, nil
+}
+
+// pruneHistory deletes the oldest finished Enchantments beyond limit, a nil limit keeps all of them
+func (r *ForgeScheduleReconciler) pruneHistory(ctx context.Context, finished []*enchv1.Enchantment, limit *int32) {
+	if limit == nil || len(finished) <= int(*limit) {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreationTimestamp.Before(&finished[j].CreationTimestamp)
+	})
+	for _, child := range finished[:len(finished)-int(*limit)] {
+		if !child.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, child, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			log.FromContext(ctx).Error(err, "unable to delete old Enchantment", "enchantment", child.Name)
+		}
+	}
+}
+
+func (r *ForgeScheduleReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
+
+// scheduledAt reads the run a child Enchantment was created for
+func scheduledAt(ench *enchv1.Enchantment) *metav1.Time {
+	t, err := time.Parse(time.RFC3339, ench.Annotations[annKeyScheduledAt])
+	if err != nil {
+		return nil
+	}
+	mt := metav1.NewTime(t)
+	return &mt
+}
+
+func setScheduleCondition(status *enchv1.ForgeScheduleStatus, s metav1.ConditionStatus, reason, message string) {
+	apimeta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    enchv1.ConditionReady,
+		Status:  s,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// SetupIndexes registers the cache indexes the reconciler lists by.
+func (r *ForgeScheduleReconciler) SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx,
+		&enchv1.Enchantment{}, scheduleOwnerIndex,
+		func(obj client.Object) []string {
+			if owner := metav1.GetControllerOf(obj); owner != nil &&
+				owner.APIVersion == enchv1.GroupVersion.String() &&
+				owner.Kind == "ForgeSchedule" {
+				return []string{string(owner.UID)}
+			}
+			return nil
+		})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ForgeScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.SetupIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+	if r.Clock == nil {
+		r.Clock = realClock{}
+	}
+	r.Recorder = mgr.GetEventRecorderFor("runesmith-operator")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&enchv1.ForgeSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&enchv1.Enchantment{}, builder.WithPredicates(childPhaseChangedPredicate())).
+		Named("forgeschedule").
+		Complete(r)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+var _ = Describe("ForgeSchedule Controller", func() {
+	const resourceName = "soak"
+
+	key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+	var (
+		reconciler *ForgeScheduleReconciler
+		clock      *fakeClock
+		created    time.Time
+	)
+
+	fetch := func(g Gomega) *enchantmentv1.ForgeSchedule {
+		var fs enchantmentv1.ForgeSchedule
+		g.Expect(cachedClient.Get(ctx, key, &fs)).To(Succeed())
+		return &fs
+	}
+	reconcileOnce := func() reconcile.Result {
+		res, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+	children := func(g Gomega) map[string]*enchantmentv1.Enchantment {
+		return childEnchantments(g, scheduleOwnerIndex, fetch(g).UID)
+	}
+	// runName is the child of the run at created + offset, runs are due every five minutes
+	runName := func(offset time.Duration) string {
+		return fmt.Sprintf("%s-%d", resourceName, created.Add(offset).Truncate(5*time.Minute).Unix())
+	}
+	create := func(policy enchantmentv1.ConcurrencyPolicy) {
+		successful := int32(0)
+		fs := &enchantmentv1.ForgeSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: enchantmentv1.ForgeScheduleSpec{
+				Schedule:               "*/5 * * * *",
+				ConcurrencyPolicy:      policy,
+				OrderID:                16,
+				Template:               enchantmentv1.EnchantmentBatchTemplate{Cost: 1},
+				SuccessfulHistoryLimit: &successful,
+			},
+		}
+		Expect(k8sClient.Create(ctx, fs)).To(Succeed())
+		Eventually(func() error { return cachedClient.Get(ctx, key, &enchantmentv1.ForgeSchedule{}) }).Should(Succeed())
+		created = fs.CreationTimestamp.Time
+	}
+
+	BeforeEach(func() {
+		createRecipe(&enchantmentv1.Recipe{
+			ObjectMeta: metav1.ObjectMeta{Name: "ember-blade"},
+			Spec: enchantmentv1.RecipeSpec{ID: 1, Name: "Ember Blade", Tier: shared.Common,
+				Requirements: enchantmentv1.RecipeRequirements{Fire: 2}},
+		})
+
+		clock = &fakeClock{}
+		reconciler = &ForgeScheduleReconciler{
+			Client:   cachedClient,
+			Scheme:   cachedClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			Clock:    clock,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &enchantmentv1.Enchantment{}, client.InNamespace("default"),
+			client.HasLabels{lblKeySchedule})).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &enchantmentv1.Recipe{})).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &enchantmentv1.ForgeSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		})).To(Succeed())
+		Eventually(func() error {
+			return cachedClient.Get(ctx, key, &enchantmentv1.ForgeSchedule{})
+		}).ShouldNot(Succeed())
+	})
+
+	It("should run on schedule, skip overlapping runs and prune the history", func() {
+		create(enchantmentv1.ForbidConcurrent)
+
+		By("waiting for the first run")
+		clock.now = created
+		res := reconcileOnce()
+		Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(res.RequeueAfter).To(BeNumerically("<=", 5*time.Minute))
+		Consistently(func(g Gomega) { g.Expect(children(g)).To(BeEmpty()) }, time.Second).Should(Succeed())
+
+		By("starting only the latest of the missed runs")
+		clock.now = created.Add(11 * time.Minute)
+		reconcileOnce()
+		first := runName(11 * time.Minute)
+		Eventually(func(g Gomega) {
+			g.Expect(children(g)).To(HaveLen(1))
+			g.Expect(children(g)).To(HaveKey(first))
+			status := fetch(g).Status
+			g.Expect(status.Active).To(HaveLen(1))
+			g.Expect(status.LastScheduleTime).NotTo(BeNil())
+			g.Expect(status.LastScheduleTime.Unix()).To(Equal(created.Add(11 * time.Minute).Truncate(5 * time.Minute).Unix()))
+		}).Should(Succeed())
+		Expect(children(Default)[first].Spec.Recipe).To(Equal("ember-blade"))
+
+		By("skipping a run while the previous one is active")
+		clock.now = created.Add(16 * time.Minute)
+		reconcileOnce()
+		Consistently(func(g Gomega) { g.Expect(children(g)).To(HaveLen(1)) }, time.Second).Should(Succeed())
+
+		By("finishing the active run")
+		ench := &enchantmentv1.Enchantment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: first, Namespace: "default"}, ench)).To(Succeed())
+		ench.Status.Phase = shared.CompletedAS
+		Expect(k8sClient.Status().Update(ctx, ench)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(children(g)[first].Status.Phase).To(Equal(shared.CompletedAS))
+		}).Should(Succeed())
+
+		clock.now = created.Add(21 * time.Minute)
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			kids := children(g)
+			g.Expect(kids).To(HaveKey(runName(21 * time.Minute)))
+			g.Expect(kids).NotTo(HaveKey(first), "the history limit is 0")
+			g.Expect(fetch(g).Status.Active).To(HaveLen(1))
+		}).Should(Succeed())
+	})
+
+	It("should replace the active run", func() {
+		create(enchantmentv1.ReplaceConcurrent)
+
+		clock.now = created.Add(6 * time.Minute)
+		reconcileOnce()
+		Eventually(func(g Gomega) { g.Expect(children(g)).To(HaveKey(runName(6 * time.Minute))) }).Should(Succeed())
+
+		clock.now = created.Add(11 * time.Minute)
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			kids := children(g)
+			g.Expect(kids).To(HaveLen(1))
+			g.Expect(kids).To(HaveKey(runName(11 * time.Minute)))
+			active := fetch(g).Status.Active
+			g.Expect(active).To(HaveLen(1))
+			g.Expect(active[0].Name).To(Equal(runName(11 * time.Minute)))
+		}).Should(Succeed())
+	})
+})
+
+var _ = Describe("ForgeSchedule missed runs", func() {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newSchedule := func() *enchantmentv1.ForgeSchedule {
+		return &enchantmentv1.ForgeSchedule{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+	}
+
+	It("should count the runs missed since the last one", func() {
+		sched, err := cron.ParseStandard("*/5 * * * *")
+		Expect(err).NotTo(HaveOccurred())
+		run, missed, next := nextRuns(newSchedule(), &enchantmentv1.ForgeScheduleStatus{}, sched, created.Add(17*time.Minute))
+		Expect(run).To(Equal(created.Add(15 * time.Minute)))
+		Expect(missed).To(Equal(3))
+		Expect(next).To(Equal(created.Add(20 * time.Minute)))
+	})
+
+	It("should stop counting past maxMissedRuns and still find the latest run", func() {
+		sched, err := cron.ParseStandard("* * * * *")
+		Expect(err).NotTo(HaveOccurred())
+		now := created.Add(365*24*time.Hour + 30*time.Second)
+		run, missed, next := nextRuns(newSchedule(), &enchantmentv1.ForgeScheduleStatus{}, sched, now)
+		Expect(missed).To(Equal(maxMissedRuns + 1))
+		Expect(run).To(Equal(created.Add(365 * 24 * time.Hour)))
+		Expect(next).To(Equal(run.Add(time.Minute)))
+	})
+
+	It("should find the latest run of an uneven schedule", func() {
+		// weekdays only, the last run before a Monday morning is on Friday
+		sched, err := cron.ParseStandard("0 9 * * 1-5")
+		Expect(err).NotTo(HaveOccurred())
+		now := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+		run, missed, _ := nextRuns(newSchedule(), &enchantmentv1.ForgeScheduleStatus{}, sched, now)
+		Expect(missed).To(Equal(maxMissedRuns + 1))
+		Expect(run).To(Equal(time.Date(2025, 5, 30, 9, 0, 0, 0, time.UTC)))
+	})
+})
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// gangSchedulingGate keeps the pods of a gang off the nodes, and so off the mana devices, until every
+// requirement Job is admitted
+const gangSchedulingGate = "enchantment.runesmith.io/gang"
+
+func gangSchedulingGates(ench *enchv1.Enchantment) []corev1.PodSchedulingGate {
+	if !ench.Spec.GangScheduling {
+		return nil
+	}
+	return []corev1.PodSchedulingGate{{Name: gangSchedulingGate}}
+}
+
+// admittedJobs counts the jobs kueue already unsuspended
+func admittedJobs(jobs map[shared.Elemental]*batchv1.Job) int {
+	var n int
+	for _, job := range jobs {
+		if job.Spec.Suspend == nil || !*job.Spec.Suspend {
+			n++
+		}
+	}
+	return n
+}
+
+// releaseGang lifts the gang gate from the pods of the given jobs. Pods the job controller creates later
+// bump the job's active count, the job watch brings us back for them.
+func (r *EnchantmentReconciler) releaseGang(ctx context.Context, jobs map[shared.Elemental]*batchv1.Job) error {
+	for _, job := range jobs {
+		if isJobFinished(job) {
+			continue
+		}
+		var pods corev1.PodList
+		if err := r.List(ctx, &pods,
+			client.InNamespace(job.Namespace),
+			client.MatchingLabels{batchv1.JobNameLabel: job.Name},
+		); err != nil {
+			return err
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			gates := make([]corev1.PodSchedulingGate, 0, len(pod.Spec.SchedulingGates))
+			for _, g := range pod.Spec.SchedulingGates {
+				if g.Name != gangSchedulingGate {
+					gates = append(gates, g)
+				}
+			}
+			if len(gates) == len(pod.Spec.SchedulingGates) {
+				continue
+			}
+			patch := client.MergeFrom(pod.DeepCopy())
+			pod.Spec.SchedulingGates = gates
+			if err := r.Patch(ctx, pod, patch); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
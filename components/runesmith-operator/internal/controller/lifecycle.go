@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func isTerminalPhase(phase shared.EnchantmentPhase) bool {
+	switch phase {
+	case shared.CompletedAS, shared.FailedAS, shared.CancelledAS, shared.DeletedAS:
+		return true
+	}
+	return false
+}
+
+// pause suspends the jobs of the Enchantment and deactivates their Workloads, kueue would readmit a
+// suspended job otherwise. It runs on every reconcile while spec.suspend is set to catch such readmissions.
+func (r *EnchantmentReconciler) pause(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	jobs, err := r.ownedJobs(ctx, ench)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = r.setWorkloadsActive(ctx, ench.Namespace, jobs, false); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range jobs {
+		if _, err = r.suspendJob(ctx, &jobs[i]); err != nil {
+			logger.Error(err, "failed to suspend job", "job", jobs[i].Name)
+			return ctrl.Result{}, err
+		}
+	}
+	if phase == shared.PausedAS {
+		return ctrl.Result{}, nil
+	}
+
+	latest := latestJobs(jobs)
+	view, err := r.listWorkloads(ctx, ench.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	requirements := make([]enchv1.EnchantmentRequirementStatus, 0, len(latest))
+	for _, ess := range ench.Spec.Artifact.Requirements {
+		job, ok := latest[ess.EnergyType]
+		if !ok {
+			continue
+		}
+		rs, err := r.requirementStatus(ctx, job, view)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		requirements = append(requirements, rs)
+	}
+
+	ptr.phase = shared.PausedAS.Ptr()
+	ptr.requirements = requirements
+	ptr.setCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, enchv1.ReasonPaused, "enchantment is paused")
+	ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonPaused, "enchantment is paused")
+	ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonPaused, "enchantment is paused")
+	if err = r.reconcileStatus(ctx, ptr); err != nil {
+		logger.Error(err, "failed to update Enchantment status", "from", phase, "to", shared.PausedAS)
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	jobStates.set(client.ObjectKeyFromObject(ench), requirements)
+	r.Recorder.Eventf(ench, corev1.EventTypeNormal, "EnchantmentPaused", "paused in phase %s", phase)
+	return ctrl.Result{}, nil
+}
+
+// resume hands the jobs back to kueue. The Enchantment goes back to Scheduled if none of its jobs ever
+// started, to Requeued otherwise, and the regular flow takes it from there.
+func (r *EnchantmentReconciler) resume(ctx context.Context, ench *enchv1.Enchantment, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	jobs, err := r.ownedJobs(ctx, ench)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = r.setWorkloadsActive(ctx, ench.Namespace, jobs, true); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	next := shared.ScheduledAS
+	reason := enchv1.ReasonWaitingForAdmission
+	for _, job := range latestJobs(jobs) {
+		if job.Status.StartTime != nil {
+			next, reason = shared.RequeuedAS, enchv1.ReasonRequeued
+			break
+		}
+	}
+	ptr.phase = next.Ptr()
+	// the queue timeout counts from here, the time spent Paused isn't waiting for admission
+	ptr.restartCondition(enchv1.ConditionAdmitted, metav1.ConditionFalse, reason, "enchantment resumed, jobs are queued", r.now())
+	ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, reason, "enchantment resumed, jobs are queued")
+	if err = r.reconcileStatus(ctx, ptr); err != nil {
+		logger.Error(err, "failed to update Enchantment status", "from", shared.PausedAS, "to", next)
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	r.Recorder.Eventf(ench, corev1.EventTypeNormal, "EnchantmentResumed", "resumed as %s", next)
+	return ctrl.Result{}, nil
+}
+
+// cancel deletes the jobs and ends the Enchantment as Cancelled. The status stays as it was otherwise so
+// that the history is there until the ttl removes the Enchantment.
+func (r *EnchantmentReconciler) cancel(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	jobs, err := r.ownedJobs(ctx, ench)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	policy := metav1.DeletePropagationBackground
+	for i := range jobs {
+		job := &jobs[i]
+		if !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// suspend first so that kueue releases the quota and pods stop before the job goes away
+		if _, err = r.suspendJob(ctx, job); err != nil {
+			logger.Error(err, "failed to suspend job", "job", job.Name)
+			return ctrl.Result{}, err
+		}
+		if err = r.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &policy}); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "failed to delete job", "job", job.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	ptr.phase = shared.CancelledAS.Ptr()
+	ptr.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, enchv1.ReasonCancelled, "enchantment is cancelled")
+	ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonCancelled, "enchantment is cancelled")
+	markCompletion(ench, ptr, r.now())
+	if err = r.reconcileStatus(ctx, ptr); err != nil {
+		logger.Error(err, "failed to update Enchantment status", "from", phase, "to", shared.CancelledAS)
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	observeFinished(ench, shared.CancelledAS, r.now())
+	r.Recorder.Eventf(ench, corev1.EventTypeNormal, "EnchantmentCancelled", "cancelled in phase %s, deleted %d jobs", phase, len(jobs))
+	logger.Info("enchantment cancelled", "name", ench.Name, "from", phase)
+	if ptr.expiresAt != nil {
+		return ctrl.Result{RequeueAfter: ptr.expiresAt.Sub(r.now())}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *EnchantmentReconciler) ownedJobs(ctx context.Context, ench *enchv1.Enchantment) ([]batchv1.Job, error) {
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs,
+		client.InNamespace(ench.Namespace),
+		client.MatchingFields{jobOwnerIndex: string(ench.UID)},
+	); err != nil {
+		return nil, err
+	}
+	return jobs.Items, nil
+}
+
+// setWorkloadsActive flips spec.active on the Workloads of the given jobs, a no-op without kueue
+func (r *EnchantmentReconciler) setWorkloadsActive(ctx context.Context, namespace string, jobs []batchv1.Job, active bool) error {
+	if !r.kueueEnabled || len(jobs) == 0 {
+		return nil
+	}
+	view, err := r.listWorkloads(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		wl, ok := view.byJob[jobs[i].Name]
+		if !ok || isJobFinished(&jobs[i]) {
+			continue
+		}
+		if wl.Spec.Active != nil && *wl.Spec.Active == active || wl.Spec.Active == nil && active {
+			continue
+		}
+		patch := client.MergeFrom(wl.DeepCopy())
+		wl.Spec.Active = &active
+		if err = r.Patch(ctx, wl, patch); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+// ManaBudgetReconciler reconciles a ManaBudget object. It reports the usage of the namespace, the
+// EnchantmentReconciler enforces the limits and charges the spend of completed Enchantments.
+type ManaBudgetReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Clock defaults to the wall clock
+	Clock Clock
+}
+
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=manabudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=manabudgets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=manabudgets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=enchantment.runesmith.io,resources=enchantments,verbs=get;list;watch
+
+// Reconcile recomputes the concurrent usage of the namespace and drops the spend that left the window.
+func (r *ManaBudgetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	budget := &enchv1.ManaBudget{}
+	if err := r.Get(ctx, req.NamespacedName, budget); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("mana budget resource not found. ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "failed to get ManaBudget")
+		return ctrl.Result{}, err
+	}
+
+	var list enchv1.EnchantmentList
+	if err := r.List(ctx, &list, client.InNamespace(budget.Namespace)); err != nil {
+		logger.Error(err, "failed to list Enchantments")
+		return ctrl.Result{}, err
+	}
+
+	now := r.now()
+	status := budget.Status.DeepCopy()
+	status.Concurrent = concurrentUsage(list.Items)
+	status.Spend, status.SpentToday = inWindow(status.Spend, now)
+	status.ObservedGeneration = budget.Generation
+
+	var exhausted []string
+	for _, energy := range []shared.Elemental{shared.FireEnergy, shared.FrostEnergy, shared.ArcaneEnergy} {
+		if limit, ok := budget.Spec.Concurrent.Limit(energy); ok && status.Concurrent.Of(energy) >= limit {
+			exhausted = append(exhausted, fmt.Sprintf("%d of %d %s mana held", status.Concurrent.Of(energy), limit, energy))
+		}
+	}
+	switch {
+	case len(exhausted) > 0:
+		setBudgetCondition(status, budget.Generation, metav1.ConditionTrue, enchv1.ReasonConcurrentLimitReached,
+			strings.Join(exhausted, ", "))
+	case budget.Spec.DailySpend != nil && status.SpentToday >= *budget.Spec.DailySpend:
+		setBudgetCondition(status, budget.Generation, metav1.ConditionTrue, enchv1.ReasonDailySpendReached,
+			fmt.Sprintf("%d of %d daily mana spent", status.SpentToday, *budget.Spec.DailySpend))
+	default:
+		setBudgetCondition(status, budget.Generation, metav1.ConditionFalse, enchv1.ReasonWithinBudget, "new Enchantments fit the budget")
+	}
+
+	if !equality.Semantic.DeepEqual(&budget.Status, status) {
+		// the EnchantmentReconciler adds to the spend concurrently, don't overwrite what it added
+		patch := client.MergeFromWithOptions(budget.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		budget.Status = *status
+		if err := r.Status().Patch(ctx, budget, patch); err != nil {
+			if errors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			logger.Error(err, "failed to update ManaBudget status")
+			return ctrl.Result{}, err
+		}
+	}
+	// SpentToday drops once the oldest spend leaves the window
+	return ctrl.Result{RequeueAfter: nextRollover(status.Spend, now)}, nil
+}
+
+func (r *ManaBudgetReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
+
+func setBudgetCondition(status *enchv1.ManaBudgetStatus, generation int64, s metav1.ConditionStatus, reason, message string) {
+	apimeta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               enchv1.ConditionExhausted,
+		Status:             s,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// budgetsForEnchantment wakes up the ManaBudgets of the namespace an Enchantment moved in
+func (r *ManaBudgetReconciler) budgetsForEnchantment(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list enchv1.ManaBudgetList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list mana budgets", "namespace", obj.GetNamespace())
+		return nil
+	}
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for _, budget := range list.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&budget)})
+	}
+	return reqs
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManaBudgetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Clock == nil {
+		r.Clock = realClock{}
+	}
+	r.Recorder = mgr.GetEventRecorderFor("runesmith-operator")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&enchv1.ManaBudget{}).
+		Watches(&enchv1.Enchantment{}, handler.EnqueueRequestsFromMapFunc(r.budgetsForEnchantment),
+			builder.WithPredicates(phaseChangedPredicate())).
+		Named("manabudget").
+		Complete(r)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/fukaraca/runesmith/shared"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+// budgetEnchantment needs limit fire mana, with jobs it holds the mana
+func budgetEnchantment(name string, limit int, withJobs bool) *enchantmentv1.Enchantment {
+	ench := newEnchantment(name, 19)
+	ench.Spec.Cost = 2
+	ench.Spec.Artifact.Requirements[0].Limit = limit
+	Expect(k8sClient.Create(ctx, ench)).To(Succeed())
+	if withJobs {
+		ench.Status.Phase = shared.EnchantingAS
+		apimeta.SetStatusCondition(&ench.Status.Conditions, metav1.Condition{
+			Type: enchantmentv1.ConditionJobsCreated, Status: metav1.ConditionTrue,
+			Reason: enchantmentv1.ReasonJobsCreated, Message: "jobs created",
+		})
+		Expect(k8sClient.Status().Update(ctx, ench)).To(Succeed())
+	}
+	Eventually(func(g Gomega) {
+		var cached enchantmentv1.Enchantment
+		g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(ench), &cached)).To(Succeed())
+		g.Expect(cached.Status.Phase).To(Equal(ench.Status.Phase))
+	}).Should(Succeed())
+	return ench
+}
+
+var _ = Describe("ManaBudget Controller", func() {
+	const resourceName = "team-budget"
+
+	key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+	var (
+		reconciler *ManaBudgetReconciler
+		clock      *fakeClock
+	)
+
+	fetch := func(g Gomega) *enchantmentv1.ManaBudget {
+		var budget enchantmentv1.ManaBudget
+		g.Expect(cachedClient.Get(ctx, key, &budget)).To(Succeed())
+		return &budget
+	}
+	reconcileOnce := func() reconcile.Result {
+		res, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	BeforeEach(func() {
+		fire, daily := 3, int64(100)
+		budget := &enchantmentv1.ManaBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: enchantmentv1.ManaBudgetSpec{
+				Concurrent: enchantmentv1.ManaLimits{Fire: &fire},
+				DailySpend: &daily,
+			},
+		}
+		Expect(k8sClient.Create(ctx, budget)).To(Succeed())
+		Eventually(func() error { return cachedClient.Get(ctx, key, &enchantmentv1.ManaBudget{}) }).Should(Succeed())
+
+		clock = &fakeClock{now: time.Now()}
+		reconciler = &ManaBudgetReconciler{
+			Client:   cachedClient,
+			Scheme:   cachedClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			Clock:    clock,
+		}
+	})
+
+	AfterEach(func() {
+		removeEnchantments("budget-running", "budget-paused")
+		Expect(k8sClient.Delete(ctx, &enchantmentv1.ManaBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+		})).To(Succeed())
+		Eventually(func() error { return cachedClient.Get(ctx, key, &enchantmentv1.ManaBudget{}) }).ShouldNot(Succeed())
+	})
+
+	It("should report the mana held by Enchantments with jobs", func() {
+		budgetEnchantment("budget-running", 3, true)
+		budgetEnchantment("budget-paused", 2, false)
+
+		reconcileOnce()
+		Eventually(func(g Gomega) {
+			budget := fetch(g)
+			g.Expect(budget.Status.Concurrent).To(Equal(enchantmentv1.ManaUsage{Fire: 3}))
+			cond := apimeta.FindStatusCondition(budget.Status.Conditions, enchantmentv1.ConditionExhausted)
+			g.Expect(cond).NotTo(BeNil())
+			g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonConcurrentLimitReached))
+		}).Should(Succeed())
+	})
+
+	It("should forget the spend that left the window", func() {
+		budget := fetch(Default)
+		hour := clock.now.Truncate(time.Hour)
+		budget.Status.Spend = []enchantmentv1.ManaSpend{
+			{Hour: metav1.NewTime(hour.Add(-25 * time.Hour)), Mana: 80},
+			{Hour: metav1.NewTime(hour), Mana: 100},
+		}
+		Expect(k8sClient.Status().Update(ctx, budget)).To(Succeed())
+		Eventually(func(g Gomega) { g.Expect(fetch(g).Status.Spend).To(HaveLen(2)) }).Should(Succeed())
+
+		res := reconcileOnce()
+		Expect(res.RequeueAfter).To(Equal(hour.Add(spendWindow).Sub(clock.now)))
+		Eventually(func(g Gomega) {
+			budget := fetch(g)
+			g.Expect(budget.Status.Spend).To(HaveLen(1))
+			g.Expect(budget.Status.SpentToday).To(Equal(int64(100)))
+			cond := apimeta.FindStatusCondition(budget.Status.Conditions, enchantmentv1.ConditionExhausted)
+			g.Expect(cond).NotTo(BeNil())
+			g.Expect(cond.Reason).To(Equal(enchantmentv1.ReasonDailySpendReached))
+		}).Should(Succeed())
+
+		By("freeing the budget once the last spend rolled over")
+		clock.now = hour.Add(spendWindow)
+		Expect(reconcileOnce().RequeueAfter).To(BeZero())
+		Eventually(func(g Gomega) {
+			budget := fetch(g)
+			g.Expect(budget.Status.SpentToday).To(BeZero())
+			g.Expect(apimeta.IsStatusConditionFalse(budget.Status.Conditions, enchantmentv1.ConditionExhausted)).To(BeTrue())
+		}).Should(Succeed())
+	})
+})
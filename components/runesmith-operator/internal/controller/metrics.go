@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const metricsNamespace = "runesmith"
+
+// durations range from seconds of queue wait up to a couple of hours of enchanting
+var durationBuckets = prometheus.ExponentialBuckets(1, 2, 14)
+
+var (
+	enchantmentsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "enchantments_finished_total",
+		Help:      "Enchantments that reached a terminal phase, by phase and tier.",
+	}, []string{"phase", "tier"})
+
+	scheduledDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "enchantment_scheduled_duration_seconds",
+		Help:      "Time from creation until all jobs of an Enchantment were admitted.",
+		Buckets:   durationBuckets,
+	}, []string{"tier"})
+
+	enchantingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "enchantment_enchanting_duration_seconds",
+		Help:      "Time from the last admission of an Enchantment until it finished.",
+		Buckets:   durationBuckets,
+	}, []string{"phase", "tier"})
+
+	endToEndDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "enchantment_duration_seconds",
+		Help:      "Time from creation of an Enchantment until it reached a terminal phase.",
+		Buckets:   durationBuckets,
+	}, []string{"phase", "tier"})
+
+	enchantmentJobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "enchantment_jobs",
+		Help:      "Jobs of in-flight Enchantments by energy type and state (active or suspended).",
+	}, []string{"energy_type", "state"})
+
+	jobCreateFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_create_failures_total",
+		Help:      "Failed attempts to create an enchanter Job, by energy type.",
+	}, []string{"energy_type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		enchantmentsFinished,
+		scheduledDuration,
+		enchantingDuration,
+		endToEndDuration,
+		enchantmentJobs,
+		jobCreateFailures,
+	)
+}
+
+// observeAdmitted records the queue wait once an Enchantment leaves Scheduled
+func observeAdmitted(ench *enchv1.Enchantment, now time.Time) {
+	scheduledDuration.WithLabelValues(string(ench.Spec.Artifact.Tier)).
+		Observe(now.Sub(ench.CreationTimestamp.Time).Seconds())
+}
+
+// observeFinished records a terminal transition. Call it only after the phase is persisted so that a
+// retried status patch doesn't count twice.
+func observeFinished(ench *enchv1.Enchantment, phase shared.EnchantmentPhase, now time.Time) {
+	tier := string(ench.Spec.Artifact.Tier)
+	enchantmentsFinished.WithLabelValues(string(phase), tier).Inc()
+	endToEndDuration.WithLabelValues(string(phase), tier).Observe(now.Sub(ench.CreationTimestamp.Time).Seconds())
+	if c := apimeta.FindStatusCondition(ench.Status.Conditions, enchv1.ConditionAdmitted); c != nil && c.Status == metav1.ConditionTrue {
+		enchantingDuration.WithLabelValues(string(phase), tier).Observe(now.Sub(c.LastTransitionTime.Time).Seconds())
+	}
+	jobStates.forget(client.ObjectKeyFromObject(ench))
+}
+
+// jobStateTracker keeps the latest requirement states of every in-flight Enchantment so that the
+// enchantment_jobs gauge can be rebuilt from whole numbers instead of drifting with inc/dec.
+type jobStateTracker struct {
+	mu     sync.Mutex
+	states map[client.ObjectKey][]enchv1.EnchantmentRequirementStatus
+}
+
+var jobStates = &jobStateTracker{states: map[client.ObjectKey][]enchv1.EnchantmentRequirementStatus{}}
+
+func (t *jobStateTracker) set(key client.ObjectKey, requirements []enchv1.EnchantmentRequirementStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.states[key] = requirements
+	t.publish()
+}
+
+func (t *jobStateTracker) forget(key client.ObjectKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.states[key]; !ok {
+		return
+	}
+	delete(t.states, key)
+	t.publish()
+}
+
+// publish must be called with mu held
+func (t *jobStateTracker) publish() {
+	active := map[shared.Elemental]int{}
+	suspended := map[shared.Elemental]int{}
+	for _, reqs := range t.states {
+		for _, rs := range reqs {
+			switch rs.State {
+			case enchv1.RequirementRunning:
+				active[rs.EnergyType]++
+			case enchv1.RequirementSuspended, enchv1.RequirementPreempted:
+				suspended[rs.EnergyType]++
+			}
+		}
+	}
+	for _, energy := range []shared.Elemental{shared.FireEnergy, shared.FrostEnergy, shared.ArcaneEnergy} {
+		enchantmentJobs.WithLabelValues(energy.String(), "active").Set(float64(active[energy]))
+		enchantmentJobs.WithLabelValues(energy.String(), "suspended").Set(float64(suspended[energy]))
+	}
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	profileIndex = "profileIndex"
+	// annKeyLocalQueue on a Namespace, as an annotation or a label, names the LocalQueue of its Enchantments
+	annKeyLocalQueue = "enchantment.runesmith.io/local-queue"
+
+	defaultMaxExecTimeSeconds = 360
+	defaultEnchanterPort      = 8080
+)
+
+// profileName is the EnchanterProfile an Enchantment is built from, empty means the built-in defaults
+func (r *EnchantmentReconciler) profileName(ench *enchv1.Enchantment) string {
+	if ench.Spec.Profile != "" {
+		return ench.Spec.Profile
+	}
+	return r.DefaultProfile
+}
+
+// resolveProfile fetches the profile of the Enchantment and fills whatever it leaves empty with the
+// operator defaults. A missing profile surfaces as NotFound, the queue comes from the namespace unless
+// the profile names one.
+func (r *EnchantmentReconciler) resolveProfile(ctx context.Context, ench *enchv1.Enchantment) (*enchv1.EnchanterProfileSpec, error) {
+	spec := &enchv1.EnchanterProfileSpec{}
+	if name := r.profileName(ench); name != "" {
+		var profile enchv1.EnchanterProfile
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, &profile); err != nil {
+			return nil, err
+		}
+		spec = profile.Spec.DeepCopy()
+	}
+
+	if spec.Image == "" {
+		spec.Image = r.Image
+	}
+	if spec.ImagePullPolicy == "" {
+		spec.ImagePullPolicy = corev1.PullIfNotPresent
+	}
+	if spec.QueueName == "" {
+		queue, err := r.namespaceQueue(ctx, ench.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		spec.QueueName = queue
+	}
+	if spec.MaxExecTimeSeconds == 0 {
+		spec.MaxExecTimeSeconds = defaultMaxExecTimeSeconds
+	}
+	if spec.Port == 0 {
+		spec.Port = defaultEnchanterPort
+	}
+	if spec.LivenessProbe == nil {
+		spec.LivenessProbe = &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/healthz",
+					Port: intstr.FromInt32(spec.Port),
+				},
+			},
+			InitialDelaySeconds: 2,
+			PeriodSeconds:       2,
+		}
+	}
+	if spec.ReadinessProbe == nil {
+		spec.ReadinessProbe = &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/readyz",
+					Port: intstr.FromInt32(spec.Port),
+				},
+			},
+			InitialDelaySeconds: 2,
+			PeriodSeconds:       1,
+		}
+	}
+	return spec, nil
+}
+
+// namespaceQueue is the LocalQueue a team picked for its namespace, the annotation wins over the label.
+// Namespaces that don't pick one share the manager's default queue.
+func (r *EnchantmentReconciler) namespaceQueue(ctx context.Context, namespace string) (string, error) {
+	var ns corev1.Namespace
+	err := r.Get(ctx, client.ObjectKey{Name: namespace}, &ns)
+	if client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+	if queue := ns.Annotations[annKeyLocalQueue]; queue != "" {
+		return queue, nil
+	}
+	if queue := ns.Labels[annKeyLocalQueue]; queue != "" {
+		return queue, nil
+	}
+	if r.DefaultQueue != "" {
+		return r.DefaultQueue, nil
+	}
+	return localKueue, nil
+}
+
+// enchantmentsForProfile wakes up the Enchantments that are still waiting for their jobs once a profile
+// shows up or changes. Running ones keep the jobs they were created with.
+func (r *EnchantmentReconciler) enchantmentsForProfile(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list enchv1.EnchantmentList
+	if err := r.List(ctx, &list, client.MatchingFields{profileIndex: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list enchantments for profile", "profile", obj.GetName())
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, ench := range list.Items {
+		if ench.Status.Phase != "" && ench.Status.Phase != shared.ScheduledAS {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ench)})
+	}
+	return reqs
+}
+
+// mergeLabels copies overlay first so that the operator's own keys win
+func mergeLabels(overlay, own map[string]string) map[string]string {
+	out := make(map[string]string, len(overlay)+len(own))
+	for k, v := range overlay {
+		out[k] = v
+	}
+	for k, v := range own {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/shared"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const recipeIndex = "recipeIndex"
+
+// pinRecipe resolves spec.recipe into status.artifact. The Enchantment keeps that artifact for its whole
+// life so a Recipe edit never changes the requirements of jobs that already exist.
+func (r *EnchantmentReconciler) pinRecipe(ctx context.Context, ench *enchv1.Enchantment,
+	phase shared.EnchantmentPhase, ptr *ptrStatus) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	var recipe enchv1.Recipe
+	err := r.Get(ctx, client.ObjectKey{Name: ench.Spec.Recipe}, &recipe)
+	if errors.IsNotFound(err) {
+		// the Recipe watch brings us back once it shows up
+		msg := fmt.Sprintf("Recipe %q not found", ench.Spec.Recipe)
+		r.Recorder.Event(ench, corev1.EventTypeWarning, enchv1.ReasonRecipeNotFound, msg)
+		ptr.phase = phase.Ptr()
+		ptr.setCondition(enchv1.ConditionJobsCreated, metav1.ConditionFalse, enchv1.ReasonRecipeNotFound, msg)
+		ptr.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, enchv1.ReasonRecipeNotFound, msg)
+		if err = r.reconcileStatus(ctx, ptr); err != nil {
+			logger.Error(err, "failed to update Enchantment status")
+			return ctrl.Result{RequeueAfter: time.Second}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	artifact := recipe.Artifact()
+	ptr.phase = phase.Ptr()
+	ptr.artifact = &artifact
+	ptr.setCondition(enchv1.ConditionJobsCreated, metav1.ConditionFalse, enchv1.ReasonRecipeResolved,
+		fmt.Sprintf("requirements resolved from Recipe %q", recipe.Name))
+	if err = r.reconcileStatus(ctx, ptr); err != nil {
+		logger.Error(err, "failed to pin Recipe", "recipe", recipe.Name)
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+	r.Recorder.Eventf(ench, corev1.EventTypeNormal, enchv1.ReasonRecipeResolved, "resolved Recipe %s as %s", recipe.Name, artifact.Name)
+	// the status update brings us back to create the jobs from the pinned artifact
+	return ctrl.Result{}, nil
+}
+
+// enchantmentsForRecipe wakes up the Enchantments still waiting for the Recipe they reference
+func (r *EnchantmentReconciler) enchantmentsForRecipe(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list enchv1.EnchantmentList
+	if err := r.List(ctx, &list, client.MatchingFields{recipeIndex: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list enchantments for recipe", "recipe", obj.GetName())
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, ench := range list.Items {
+		if ench.Status.Artifact != nil {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ench)})
+	}
+	return reqs
+}
+
+// matchingRecipes lists the catalog entries the selector allows, all of them when it is empty
+func matchingRecipes(ctx context.Context, c client.Reader, sel enchv1.EnchantmentBatchSelector) ([]enchv1.Recipe, error) {
+	var list enchv1.RecipeList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	recipes := make([]enchv1.Recipe, 0, len(list.Items))
+	for _, recipe := range list.Items {
+		if sel.ItemID != nil && recipe.Spec.ID != *sel.ItemID {
+			continue
+		}
+		if sel.Tier != "" && recipe.Spec.Tier != sel.Tier {
+			continue
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
@@ -21,18 +21,32 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/fukaraca/runesmith/shared"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/components/runesmith-operator/internal/kueue"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -45,6 +59,8 @@ var (
 	testEnv   *envtest.Environment
 	cfg       *rest.Config
 	k8sClient client.Client
+	// cachedClient reads through a manager cache that carries the reconciler's indexes
+	cachedClient client.Client
 )
 
 func TestControllers(t *testing.T) {
@@ -61,12 +77,18 @@ var _ = BeforeSuite(func() {
 	var err error
 	err = enchantmentv1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = kueue.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "config", "crd", "bases"),
+			// trimmed down copies of the kueue Workload and LocalQueue CRDs, just enough to store what the operator reads
+			filepath.Join("..", "..", "test", "crds"),
+		},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -83,6 +105,28 @@ var _ = BeforeSuite(func() {
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
+
+	By("starting a manager cache with the reconciler's indexes")
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme.Scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Pod{}: {Label: PodCacheSelector()},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect((&EnchantmentReconciler{}).SetupIndexes(ctx, mgr.GetFieldIndexer())).To(Succeed())
+	Expect((&EnchantmentBatchReconciler{}).SetupIndexes(ctx, mgr.GetFieldIndexer())).To(Succeed())
+	Expect((&ForgeScheduleReconciler{}).SetupIndexes(ctx, mgr.GetFieldIndexer())).To(Succeed())
+
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(ctx)).To(Succeed())
+	}()
+	Expect(mgr.GetCache().WaitForCacheSync(ctx)).To(BeTrue())
+	cachedClient = mgr.GetClient()
 })
 
 var _ = AfterSuite(func() {
@@ -92,6 +136,128 @@ var _ = AfterSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 })
 
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// newEnchantmentReconciler reads through the manager cache and records events in a fake recorder
+func newEnchantmentReconciler() *EnchantmentReconciler {
+	return &EnchantmentReconciler{
+		Client:   cachedClient,
+		Scheme:   cachedClient.Scheme(),
+		Recorder: record.NewFakeRecorder(100),
+		Image:    "runesmith-enchanter:test",
+	}
+}
+
+// newEnchantment is an Ember Blade of the order forged from one fire mana and kept a minute after it
+// finished. Tests change what they are about before creating it.
+func newEnchantment(name string, orderID int) *enchantmentv1.Enchantment {
+	ttl := 60
+	return &enchantmentv1.Enchantment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: enchantmentv1.EnchantmentSpec{
+			Retention: enchantmentv1.EnchantmentRetentionPolicy{TTLSecondsAfterFinished: &ttl},
+			OrderID:   orderID,
+			Cost:      1,
+			Artifact: enchantmentv1.EnchantmentSpecArtifact{
+				ID:   1,
+				Name: "Ember Blade",
+				Tier: shared.Common,
+				Requirements: []enchantmentv1.EnchantmentSpecArtifactRequirement{
+					{EnergyType: shared.FireEnergy, ResourceName: shared.FireEnergy.Resource(), Limit: 1},
+				},
+			},
+		},
+	}
+}
+
+// createEnchantment creates the Enchantment and waits for the cache to see it
+func createEnchantment(ench *enchantmentv1.Enchantment) {
+	Expect(k8sClient.Create(ctx, ench)).To(Succeed())
+	Eventually(func() error {
+		return cachedClient.Get(ctx, client.ObjectKeyFromObject(ench), &enchantmentv1.Enchantment{})
+	}).Should(Succeed())
+}
+
+// fetchEnchantment reads the Enchantment through the cache
+func fetchEnchantment(g Gomega, key types.NamespacedName) *enchantmentv1.Enchantment {
+	var ench enchantmentv1.Enchantment
+	g.Expect(cachedClient.Get(ctx, key, &ench)).To(Succeed())
+	return &ench
+}
+
+// reconcileEnchantment runs a single reconcile of the Enchantment, it must not fail
+func reconcileEnchantment(r *EnchantmentReconciler, key types.NamespacedName) reconcile.Result {
+	res, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	Expect(err).NotTo(HaveOccurred())
+	return res
+}
+
+// expectEnchantmentPhase waits for the cache to show the Enchantment in the phase
+func expectEnchantmentPhase(key types.NamespacedName, phase shared.EnchantmentPhase) {
+	Eventually(func(g Gomega) { g.Expect(fetchEnchantment(g, key).Status.Phase).To(Equal(phase)) }).Should(Succeed())
+}
+
+// ownedJobs lists the jobs the Enchantment owns
+func ownedJobs(g Gomega, key types.NamespacedName) []batchv1.Job {
+	var jobs batchv1.JobList
+	g.Expect(cachedClient.List(ctx, &jobs, client.InNamespace(key.Namespace),
+		client.MatchingFields{jobOwnerIndex: string(fetchEnchantment(g, key).UID)})).To(Succeed())
+	return jobs.Items
+}
+
+// startJob unsuspends the job and starts its pod the way kueue and the job controller would, then
+// waits for the cache to see it
+func startJob(job *batchv1.Job) {
+	job.Spec.Suspend = ptr.To(false)
+	Expect(k8sClient.Update(ctx, job)).To(Succeed())
+	job.Status.StartTime = ptr.To(metav1.Now())
+	job.Status.Active = 1
+	Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+	Eventually(func(g Gomega) {
+		var cached batchv1.Job
+		g.Expect(cachedClient.Get(ctx, client.ObjectKeyFromObject(job), &cached)).To(Succeed())
+		g.Expect(cached.Status.Active).To(Equal(int32(1)))
+	}).Should(Succeed())
+}
+
+// createRecipe creates the Recipe and waits for the cache to see it
+func createRecipe(recipe *enchantmentv1.Recipe) {
+	Expect(k8sClient.Create(ctx, recipe)).To(Succeed())
+	Eventually(func() error {
+		return cachedClient.Get(ctx, client.ObjectKeyFromObject(recipe), &enchantmentv1.Recipe{})
+	}).Should(Succeed())
+}
+
+// childEnchantments lists the Enchantments a batch or schedule created, by name
+func childEnchantments(g Gomega, ownerIndex string, owner types.UID) map[string]*enchantmentv1.Enchantment {
+	var list enchantmentv1.EnchantmentList
+	g.Expect(cachedClient.List(ctx, &list, client.InNamespace("default"),
+		client.MatchingFields{ownerIndex: string(owner)})).To(Succeed())
+	byName := make(map[string]*enchantmentv1.Enchantment, len(list.Items))
+	for i := range list.Items {
+		byName[list.Items[i].Name] = &list.Items[i]
+	}
+	return byName
+}
+
+// removeEnchantments drops Enchantments of the default namespace, finalizers included
+func removeEnchantments(names ...string) {
+	for _, name := range names {
+		key := types.NamespacedName{Name: name, Namespace: "default"}
+		ench := &enchantmentv1.Enchantment{}
+		if err := k8sClient.Get(ctx, key, ench); err != nil {
+			continue
+		}
+		patch := client.MergeFrom(ench.DeepCopy())
+		controllerutil.RemoveFinalizer(ench, enchantmentFinalizer)
+		Expect(k8sClient.Patch(ctx, ench, patch)).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, ench))).To(Succeed())
+		Eventually(func() error { return cachedClient.Get(ctx, key, &enchantmentv1.Enchantment{}) }).ShouldNot(Succeed())
+	}
+}
+
 // getFirstFoundEnvTestBinaryDir locates the first binary in the specified path.
 // ENVTEST-based tests depend on specific binaries, usually located in paths set by
 // controller-runtime. When running tests directly (e.g., via an IDE) without using
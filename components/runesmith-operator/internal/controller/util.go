@@ -2,36 +2,85 @@ package controller
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
 	"github.com/fukaraca/runesmith/shared"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ptrStatus struct {
 	namespacedName             client.ObjectKey
+	generation                 int64
 	phase                      *shared.EnchantmentPhase
 	progress                   *string
 	expiresAt, completionTime  *metav1.Time
 	active, failed, successful *int
+	conditions                 []metav1.Condition
+	requirements               []enchv1.EnchantmentRequirementStatus
+	artifact                   *enchv1.EnchantmentSpecArtifact
+	// since is the resourceVersion a terminal transition was computed from, the patch conflicts once the
+	// Enchantment moved on so that a reconcile of a stale copy can't finish it twice
+	since string
+}
+
+// setCondition queues a condition to be applied by reconcileStatus. ObservedGeneration is filled there.
+func (p *ptrStatus) setCondition(condType string, status metav1.ConditionStatus, reason, message string) {
+	p.conditions = append(p.conditions, metav1.Condition{
+		Type:    condType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// restartCondition queues a condition whose transition time restarts at the given time, even if its
+// status stays the same
+func (p *ptrStatus) restartCondition(condType string, status metav1.ConditionStatus, reason, message string, at time.Time) {
+	p.conditions = append(p.conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(at),
+	})
+}
+
+// markFailed sets the conditions shared by every path that ends an Enchantment as Failed
+func (p *ptrStatus) markFailed(reason, message string) {
+	p.setCondition(enchv1.ConditionFailed, metav1.ConditionTrue, reason, message)
+	p.setCondition(enchv1.ConditionProgressing, metav1.ConditionFalse, reason, message)
+	p.setCondition(enchv1.ConditionSucceeded, metav1.ConditionFalse, reason, message)
+	p.setCondition(enchv1.ConditionReady, metav1.ConditionFalse, reason, message)
 }
 
 // markCompletion is helper to keep state uniform, it is planned to use only one reconcile and just before the reconcile
-func markCompletion(enchantment *enchv1.Enchantment, ptr *ptrStatus) {
+func markCompletion(enchantment *enchv1.Enchantment, ptr *ptrStatus, now time.Time) {
 	if enchantment.Status.CompletionTime != nil {
-		return
+		// completed before the TTL was recorded, it still counts from the completion
+		now = enchantment.Status.CompletionTime.Time
+	} else {
+		nowT := metav1.NewTime(now)
+		ptr.completionTime = &nowT
 	}
-	now := time.Now()
-	if enchantment.Spec.Retention.TTLSecondsAfterFinished != nil {
+	if enchantment.Status.ExpiresAt == nil && enchantment.Spec.Retention.TTLSecondsAfterFinished != nil &&
+		!keptByParent(enchantment) {
 		ttl := metav1.NewTime(now.Add(time.Duration(*enchantment.Spec.Retention.TTLSecondsAfterFinished) * time.Second))
 		ptr.expiresAt = &ttl
 	}
-	nowT := metav1.NewTime(now)
-	ptr.completionTime = &nowT
+}
+
+// keptByParent reports the children of a batch or a schedule. They don't expire, a batch counts them until it
+// goes away itself and a schedule prunes its history.
+func keptByParent(enchantment *enchv1.Enchantment) bool {
+	owner := metav1.GetControllerOf(enchantment)
+	return owner != nil && owner.APIVersion == enchv1.GroupVersion.String() &&
+		(owner.Kind == "EnchantmentBatch" || owner.Kind == "ForgeSchedule")
 }
 
 func determineNodeSelector(req *enchv1.EnchantmentSpecArtifactRequirement) map[string]string {
@@ -49,8 +98,20 @@ func determineTolerations(req *enchv1.EnchantmentSpecArtifactRequirement) []core
 	}}
 }
 
-func generateJobName(enchantment *enchv1.Enchantment, energyType shared.Elemental) string {
-	return fmt.Sprintf("ejob-%d-%s-", enchantment.Spec.OrderID, energyType)
+// generateJobName is deterministic per requirement and attempt, the uid prefix keeps orders with the same
+// id apart and the name well below the 63 characters the job-name pod label allows
+func generateJobName(enchantment *enchv1.Enchantment, energyType shared.Elemental, attempt int) string {
+	uid := string(enchantment.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return fmt.Sprintf("ejob-%d-%s-%s-%d", enchantment.Spec.OrderID, energyType, uid, attempt)
+}
+
+// isPermanentError tells API errors a retry can't fix apart from transient ones
+func isPermanentError(err error) bool {
+	return apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || apierrors.IsNotAcceptable(err) ||
+		apierrors.IsUnsupportedMediaType(err) || apierrors.IsMethodNotSupported(err)
 }
 
 func isJobEnchanting(jobs *batchv1.JobList) bool {
@@ -61,3 +122,81 @@ func isJobEnchanting(jobs *batchv1.JobList) bool {
 	}
 	return true
 }
+
+func isJobFinished(job *batchv1.Job) bool {
+	return job.Status.Failed > 0 || (job.Status.Succeeded > 0 && job.Status.Active == 0)
+}
+
+func requirementState(job *batchv1.Job) enchv1.RequirementJobState {
+	switch {
+	case job.Status.Failed > 0:
+		return enchv1.RequirementFailed
+	case job.Status.Succeeded > 0 && job.Status.Active == 0:
+		return enchv1.RequirementSucceeded
+	case job.Spec.Suspend != nil && *job.Spec.Suspend:
+		return enchv1.RequirementSuspended
+	case job.Status.Active > 0:
+		return enchv1.RequirementRunning
+	}
+	return enchv1.RequirementAdmitted
+}
+
+// jobFinishTime returns CompletionTime for succeeded jobs, failed jobs only carry it on their condition
+func jobFinishTime(job *batchv1.Job) *metav1.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			t := c.LastTransitionTime
+			return &t
+		}
+	}
+	return nil
+}
+
+// maxRetryBackoff caps the doubling backoff between retries of a requirement
+const maxRetryBackoff = 5 * time.Minute
+
+// jobAttempt reads the attempt a Job was created for, jobs without the annotation are the first one
+func jobAttempt(job *batchv1.Job) int {
+	if n, err := strconv.Atoi(job.Annotations[annKeyAttempt]); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// latestJobs keeps the last attempt of each requirement. Failed attempts are left in place for their logs
+// and go away with the Enchantment.
+func latestJobs(jobs []batchv1.Job) map[shared.Elemental]*batchv1.Job {
+	latest := make(map[shared.Elemental]*batchv1.Job, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+		if !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		energy := shared.Elemental(job.Labels[lblKeyEnergy])
+		if cur, ok := latest[energy]; !ok || jobAttempt(cur) < jobAttempt(job) {
+			latest[energy] = job
+		}
+	}
+	return latest
+}
+
+// retryAfter tells whether the failed job may be recreated and how long to wait for it.
+// The backoff starts when the job finished and doubles with every attempt.
+func retryAfter(policy *enchv1.EnchantmentRetryPolicy, job *batchv1.Job, now time.Time) (time.Duration, bool) {
+	attempt := jobAttempt(job)
+	if policy == nil || attempt > policy.MaxRetries {
+		return 0, false
+	}
+	backoff := time.Duration(policy.BackoffSeconds) * time.Second << (attempt - 1)
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	finished := now
+	if ft := jobFinishTime(job); ft != nil {
+		finished = ft.Time
+	}
+	return max(finished.Add(backoff).Sub(now), 0), true
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	enchv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+	"github.com/fukaraca/runesmith/components/runesmith-operator/internal/kueue"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// workloadView is a per reconcile snapshot of the Kueue Workloads of a namespace
+type workloadView struct {
+	byJob    map[string]*kueue.Workload
+	position map[string]int32
+}
+
+// listWorkloads builds the view of a namespace, it stays empty when Kueue isn't installed. Queue positions
+// count the pending Workloads of every namespace that feeds the same ClusterQueue, kueue orders them there.
+func (r *EnchantmentReconciler) listWorkloads(ctx context.Context, namespace string) (*workloadView, error) {
+	view := &workloadView{byJob: map[string]*kueue.Workload{}, position: map[string]int32{}}
+	if !r.kueueEnabled {
+		return view, nil
+	}
+	var list kueue.WorkloadList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	pending, err := r.pendingByClusterQueue(ctx, list.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list.Items {
+		wl := &list.Items[i]
+		if owner := metav1.GetControllerOf(wl); wl.Namespace == namespace && owner != nil && owner.Kind == "Job" {
+			view.byJob[owner.Name] = wl
+		}
+	}
+	for _, wls := range pending {
+		for i, wl := range wls {
+			if wl.Namespace == namespace {
+				view.position[wl.Name] = int32(i + 1)
+			}
+		}
+	}
+	return view, nil
+}
+
+// pendingByClusterQueue groups the pending Workloads by the ClusterQueue their LocalQueue feeds, in the
+// order kueue pops them: higher priority first and older first within a priority. Workloads of a LocalQueue
+// that doesn't exist aren't queued anywhere.
+func (r *EnchantmentReconciler) pendingByClusterQueue(ctx context.Context, wls []kueue.Workload) (map[string][]*kueue.Workload, error) {
+	var queues kueue.LocalQueueList
+	if err := r.List(ctx, &queues); err != nil {
+		return nil, err
+	}
+	clusterQueues := make(map[types.NamespacedName]string, len(queues.Items))
+	for _, lq := range queues.Items {
+		clusterQueues[types.NamespacedName{Namespace: lq.Namespace, Name: lq.Name}] = lq.Spec.ClusterQueue
+	}
+
+	pending := map[string][]*kueue.Workload{}
+	for i := range wls {
+		wl := &wls[i]
+		if !isWorkloadPending(wl) {
+			continue
+		}
+		if cq := clusterQueues[types.NamespacedName{Namespace: wl.Namespace, Name: wl.Spec.QueueName}]; cq != "" {
+			pending[cq] = append(pending[cq], wl)
+		}
+	}
+	for _, queued := range pending {
+		sort.SliceStable(queued, func(i, j int) bool {
+			pi, pj := workloadPriority(queued[i]), workloadPriority(queued[j])
+			if pi != pj {
+				return pi > pj
+			}
+			return queued[i].CreationTimestamp.Before(&queued[j].CreationTimestamp)
+		})
+	}
+	return pending, nil
+}
+
+// clusterQueueOf is the ClusterQueue the Workload is admitted by or queued for, empty when unknown
+func (r *EnchantmentReconciler) clusterQueueOf(ctx context.Context, wl *kueue.Workload) string {
+	if wl.Status.Admission != nil {
+		return wl.Status.Admission.ClusterQueue
+	}
+	var lq kueue.LocalQueue
+	if err := r.Get(ctx, client.ObjectKey{Namespace: wl.Namespace, Name: wl.Spec.QueueName}, &lq); err != nil {
+		return ""
+	}
+	return lq.Spec.ClusterQueue
+}
+
+// apply copies what the Workload of the job tells about admission onto the requirement status
+func (v *workloadView) apply(rs *enchv1.EnchantmentRequirementStatus, job *batchv1.Job) {
+	wl, ok := v.byJob[job.Name]
+	if !ok {
+		return
+	}
+	rs.Workload = wl.Name
+	if adm := wl.Status.Admission; adm != nil {
+		rs.ClusterQueue = adm.ClusterQueue
+		for _, psa := range adm.PodSetAssignments {
+			if flavor, ok := psa.Flavors[corev1.ResourceName(rs.EnergyType.Resource())]; ok {
+				rs.Flavor = flavor
+			}
+		}
+	}
+	if c := apimeta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadAdmitted); c != nil && c.Status == metav1.ConditionTrue {
+		admitted := c.LastTransitionTime
+		rs.AdmissionTime = &admitted
+	}
+	if pos, ok := v.position[wl.Name]; ok {
+		rs.QueuePosition = &pos
+	}
+	if rs.State == enchv1.RequirementSuspended && isWorkloadPreempted(wl) {
+		rs.State = enchv1.RequirementPreempted
+	}
+}
+
+func isWorkloadPending(wl *kueue.Workload) bool {
+	return !apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadQuotaReserved) &&
+		!apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished)
+}
+
+// isWorkloadPreempted covers both the Preempted condition of newer Kueue releases and the Evicted
+// reason older ones set
+func isWorkloadPreempted(wl *kueue.Workload) bool {
+	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadPreempted) {
+		return true
+	}
+	c := apimeta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadEvicted)
+	return c != nil && c.Status == metav1.ConditionTrue && c.Reason == kueue.WorkloadEvictedByPreemption
+}
+
+func workloadPriority(wl *kueue.Workload) int32 {
+	if wl.Spec.Priority != nil {
+		return *wl.Spec.Priority
+	}
+	return 0
+}
+
+// enchantmentsForWorkload wakes up the Enchantment owning the Workload and those waiting in the same
+// ClusterQueue, their queue positions move whenever a Workload enters, gets admitted or leaves
+func (r *EnchantmentReconciler) enchantmentsForWorkload(ctx context.Context, obj client.Object) []reconcile.Request {
+	wl, ok := obj.(*kueue.Workload)
+	if !ok {
+		return nil
+	}
+	var reqs []reconcile.Request
+	seen := make(map[types.NamespacedName]bool)
+	add := func(wl *kueue.Workload) {
+		if key, ok := r.enchantmentOfWorkload(ctx, wl); ok && !seen[key] {
+			seen[key] = true
+			reqs = append(reqs, reconcile.Request{NamespacedName: key})
+		}
+	}
+	add(wl)
+
+	cq := r.clusterQueueOf(ctx, wl)
+	if cq == "" {
+		return reqs
+	}
+	var list kueue.WorkloadList
+	if err := r.List(ctx, &list); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list workloads", "clusterQueue", cq)
+		return reqs
+	}
+	pending, err := r.pendingByClusterQueue(ctx, list.Items)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to list local queues", "clusterQueue", cq)
+		return reqs
+	}
+	for _, queued := range pending[cq] {
+		add(queued)
+	}
+	return reqs
+}
+
+// enchantmentOfWorkload follows the Workload to its Job and the Job to the Enchantment owning it
+func (r *EnchantmentReconciler) enchantmentOfWorkload(ctx context.Context, wl *kueue.Workload) (types.NamespacedName, bool) {
+	owner := metav1.GetControllerOf(wl)
+	if owner == nil || owner.Kind != "Job" {
+		return types.NamespacedName{}, false
+	}
+	var job batchv1.Job
+	if err := r.Get(ctx, client.ObjectKey{Namespace: wl.Namespace, Name: owner.Name}, &job); err != nil {
+		return types.NamespacedName{}, false
+	}
+	ench := metav1.GetControllerOf(&job)
+	if ench == nil || ench.Kind != "Enchantment" || ench.APIVersion != enchv1.GroupVersion.String() {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: job.Namespace, Name: ench.Name}, true
+}
+
+// workloadChangedPredicate only passes Workload status changes, kueue rewrites little else
+func workloadChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldWl, ok := e.ObjectOld.(*kueue.Workload)
+			if !ok {
+				return true
+			}
+			newWl, ok := e.ObjectNew.(*kueue.Workload)
+			if !ok {
+				return true
+			}
+			return !equality.Semantic.DeepEqual(oldWl.Status, newWl.Status)
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kueue
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type LocalQueueSpec struct {
+	// ClusterQueue admits the Workloads of the LocalQueue, it is shared with the LocalQueues of other namespaces.
+	ClusterQueue string `json:"clusterQueue,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// LocalQueue is where the Workloads of a namespace are queued for their ClusterQueue.
+type LocalQueue struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LocalQueueSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// LocalQueueList contains a list of LocalQueue
+type LocalQueueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LocalQueue `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LocalQueue{}, &LocalQueueList{})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kueue carries the slice of the kueue.x-k8s.io/v1beta1 Workload and LocalQueue API the operator reads.
+// Kueue itself is not a dependency, fields not declared here are dropped on decode.
+// +kubebuilder:object:generate=true
+// +kubebuilder:skip
+package kueue
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is the Kueue API version the Workload types mirror.
+	GroupVersion = schema.GroupVersion{Group: "kueue.x-k8s.io", Version: "v1beta1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme.
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+// Workload condition types and reasons, as set by Kueue.
+const (
+	WorkloadQuotaReserved = "QuotaReserved"
+	WorkloadAdmitted      = "Admitted"
+	WorkloadEvicted       = "Evicted"
+	WorkloadPreempted     = "Preempted"
+	WorkloadFinished      = "Finished"
+
+	// WorkloadEvictedByPreemption is the Evicted reason of a workload that made room for a higher priority one.
+	WorkloadEvictedByPreemption = "Preempted"
+)
+
+type WorkloadSpec struct {
+	QueueName         string `json:"queueName,omitempty"`
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	Priority          *int32 `json:"priority,omitempty"`
+	// Active false makes kueue evict the workload and keep it out of the queue.
+	Active *bool `json:"active,omitempty"`
+}
+
+type PodSetAssignment struct {
+	Name    string                         `json:"name"`
+	Flavors map[corev1.ResourceName]string `json:"flavors,omitempty"`
+	Count   *int32                         `json:"count,omitempty"`
+}
+
+type Admission struct {
+	ClusterQueue      string             `json:"clusterQueue"`
+	PodSetAssignments []PodSetAssignment `json:"podSetAssignments,omitempty"`
+}
+
+type WorkloadStatus struct {
+	Admission  *Admission         `json:"admission,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// Workload is the unit of admission Kueue creates for every queued Job.
+type Workload struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkloadSpec   `json:"spec,omitempty"`
+	Status WorkloadStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkloadList contains a list of Workload
+type WorkloadList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Workload `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Workload{}, &WorkloadList{})
+}
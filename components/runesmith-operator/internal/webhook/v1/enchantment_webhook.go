@@ -0,0 +1,249 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fukaraca/runesmith/shared"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+// DefaultTTLSecondsAfterFinished is applied when an Enchantment is created without a retention policy.
+const DefaultTTLSecondsAfterFinished = 60
+
+// log is for logging in this package.
+var enchantmentlog = logf.Log.WithName("enchantment-resource")
+
+// SetupEnchantmentWebhookWithManager registers the webhook for Enchantment in the manager.
+// defaultProfile is the manager's EnchanterProfile for Enchantments that don't name one.
+func SetupEnchantmentWebhookWithManager(mgr ctrl.Manager, defaultProfile string) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&enchantmentv1.Enchantment{}).
+		WithValidator(&EnchantmentCustomValidator{Client: mgr.GetClient(), DefaultProfile: defaultProfile}).
+		WithDefaulter(&EnchantmentCustomDefaulter{}).
+		Complete()
+}
+
+// Defaulting only runs on create. Spec is immutable afterward, so defaulting on update would turn
+// every metadata patch (finalizers, labels) of an older object into a rejected spec change.
+// +kubebuilder:webhook:path=/mutate-enchantment-runesmith-io-v1-enchantment,mutating=true,failurePolicy=fail,sideEffects=None,groups=enchantment.runesmith.io,resources=enchantments,verbs=create,versions=v1,name=menchantment-v1.kb.io,admissionReviewVersions=v1
+
+// EnchantmentCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind Enchantment when those are created.
+type EnchantmentCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &EnchantmentCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Enchantment.
+// It fills ResourceName from EnergyType, the retention TTL and SelfReport.
+func (d *EnchantmentCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	enchantment, ok := obj.(*enchantmentv1.Enchantment)
+	if !ok {
+		return fmt.Errorf("expected an Enchantment object but got %T", obj)
+	}
+	enchantmentlog.Info("Defaulting for Enchantment", "name", enchantment.GetName())
+
+	spec := &enchantment.Spec
+	for i := range spec.Artifact.Requirements {
+		req := &spec.Artifact.Requirements[i]
+		if req.ResourceName == "" {
+			req.ResourceName = req.EnergyType.Resource()
+		}
+	}
+	if spec.Retention.TTLSecondsAfterFinished == nil {
+		ttl := DefaultTTLSecondsAfterFinished
+		spec.Retention.TTLSecondsAfterFinished = &ttl
+	}
+	if spec.SelfReport == nil {
+		selfReport := true
+		spec.SelfReport = &selfReport
+	}
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-enchantment-runesmith-io-v1-enchantment,mutating=false,failurePolicy=fail,sideEffects=None,groups=enchantment.runesmith.io,resources=enchantments,verbs=create;update,versions=v1,name=venchantment-v1.kb.io,admissionReviewVersions=v1
+
+// EnchantmentCustomValidator struct is responsible for validating the Enchantment resource
+// when it is created or updated.
+type EnchantmentCustomValidator struct {
+	// Client looks up the referenced EnchanterProfile and Recipe, the checks are skipped when nil.
+	Client         client.Reader
+	DefaultProfile string
+}
+
+var _ webhook.CustomValidator = &EnchantmentCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Enchantment.
+func (v *EnchantmentCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	enchantment, ok := obj.(*enchantmentv1.Enchantment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Enchantment object but got %T", obj)
+	}
+	enchantmentlog.Info("Validation for Enchantment upon creation", "name", enchantment.GetName())
+
+	allErrs := validateRequirements(enchantment)
+	allErrs = append(allErrs, validateDependencies(enchantment)...)
+	profileErr, err := v.validateProfile(ctx, enchantment)
+	if err != nil {
+		return nil, err
+	}
+	if profileErr != nil {
+		allErrs = append(allErrs, profileErr)
+	}
+	recipeErr, err := v.validateRecipe(ctx, enchantment)
+	if err != nil {
+		return nil, err
+	}
+	if recipeErr != nil {
+		allErrs = append(allErrs, recipeErr)
+	}
+	return nil, toInvalid(enchantment, allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Enchantment.
+func (v *EnchantmentCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	enchantment, ok := newObj.(*enchantmentv1.Enchantment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Enchantment object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*enchantmentv1.Enchantment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Enchantment object for the oldObj but got %T", oldObj)
+	}
+	enchantmentlog.Info("Validation for Enchantment upon update", "name", enchantment.GetName())
+
+	var allErrs field.ErrorList
+	// suspend and cancel are the operations on a running enchantment, everything else is fixed
+	oldSpec, newSpec := old.Spec.DeepCopy(), enchantment.Spec.DeepCopy()
+	oldSpec.Suspend, newSpec.Suspend = false, false
+	oldSpec.Cancel, newSpec.Cancel = false, false
+	if !equality.Semantic.DeepEqual(oldSpec, newSpec) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "spec is immutable after creation"))
+	}
+	if old.Spec.Cancel && !enchantment.Spec.Cancel {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "cancel"), "a cancelled enchantment can't be resumed"))
+	}
+	return nil, toInvalid(enchantment, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Enchantment.
+func (v *EnchantmentCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateRequirements rejects requirements the operator can't turn into working jobs
+func validateRequirements(enchantment *enchantmentv1.Enchantment) field.ErrorList {
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "artifact", "requirements")
+	reqs := enchantment.Spec.Artifact.Requirements
+	if enchantment.Spec.Recipe != "" {
+		// the operator takes the requirements from the recipe, inline ones would be silently dropped
+		if len(reqs) > 0 {
+			allErrs = append(allErrs, field.Forbidden(path, "requirements come from spec.recipe"))
+		}
+		return allErrs
+	}
+	if len(reqs) == 0 {
+		allErrs = append(allErrs, field.Required(path, "at least one requirement is needed"))
+	}
+
+	seen := make(map[shared.Elemental]bool, len(reqs))
+	for i, req := range reqs {
+		if seen[req.EnergyType] {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("energyType"), req.EnergyType))
+		}
+		seen[req.EnergyType] = true
+
+		if want := req.EnergyType.Resource(); req.ResourceName != want {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("resourceName"), req.ResourceName,
+				fmt.Sprintf("must be %q for energy type %q", want, req.EnergyType)))
+		}
+	}
+	return allErrs
+}
+
+// validateDependencies rejects dependencies the Enchantment would wait for forever
+func validateDependencies(enchantment *enchantmentv1.Enchantment) field.ErrorList {
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "dependsOn")
+	seen := make(map[string]bool, len(enchantment.Spec.DependsOn))
+	for i, dep := range enchantment.Spec.DependsOn {
+		key := "name:" + dep.Name
+		if dep.ItemID != nil {
+			key = "item:" + strconv.Itoa(*dep.ItemID)
+		}
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i), dep))
+		}
+		seen[key] = true
+
+		if dep.ItemID == nil && dep.Name == enchantment.Name {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("name"), dep.Name, "an enchantment can't depend on itself"))
+		}
+	}
+	return allErrs
+}
+
+// validateProfile rejects Enchantments whose EnchanterProfile, named or the manager's default, doesn't exist
+func (v *EnchantmentCustomValidator) validateProfile(ctx context.Context, enchantment *enchantmentv1.Enchantment) (*field.Error, error) {
+	name := enchantment.Spec.Profile
+	if name == "" {
+		name = v.DefaultProfile
+	}
+	if name == "" || v.Client == nil {
+		return nil, nil
+	}
+	var profile enchantmentv1.EnchanterProfile
+	err := v.Client.Get(ctx, client.ObjectKey{Name: name}, &profile)
+	if apierrors.IsNotFound(err) {
+		return field.NotFound(field.NewPath("spec", "profile"), name), nil
+	}
+	return nil, err
+}
+
+// validateRecipe rejects Enchantments that reference a Recipe that doesn't exist
+func (v *EnchantmentCustomValidator) validateRecipe(ctx context.Context, enchantment *enchantmentv1.Enchantment) (*field.Error, error) {
+	name := enchantment.Spec.Recipe
+	if name == "" || v.Client == nil {
+		return nil, nil
+	}
+	var recipe enchantmentv1.Recipe
+	err := v.Client.Get(ctx, client.ObjectKey{Name: name}, &recipe)
+	if apierrors.IsNotFound(err) {
+		return field.NotFound(field.NewPath("spec", "recipe"), name), nil
+	}
+	return nil, err
+}
+
+func toInvalid(enchantment *enchantmentv1.Enchantment, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(enchantmentv1.GroupVersion.WithKind("Enchantment").GroupKind(), enchantment.Name, allErrs)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/fukaraca/runesmith/shared"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enchantmentv1 "github.com/fukaraca/runesmith/components/runesmith-operator/api/v1"
+)
+
+func newEnchantment(name string, reqs ...enchantmentv1.EnchantmentSpecArtifactRequirement) *enchantmentv1.Enchantment {
+	return &enchantmentv1.Enchantment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: enchantmentv1.EnchantmentSpec{
+			Artifact: enchantmentv1.EnchantmentSpecArtifact{
+				ID:           1,
+				Name:         "Ember Blade",
+				Tier:         shared.Common,
+				Requirements: reqs,
+			},
+			OrderID: 1,
+			Cost:    1,
+		},
+	}
+}
+
+var _ = Describe("Enchantment Webhook", func() {
+	var (
+		obj       *enchantmentv1.Enchantment
+		oldObj    *enchantmentv1.Enchantment
+		validator EnchantmentCustomValidator
+		defaulter EnchantmentCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		obj = newEnchantment("test-enchantment",
+			enchantmentv1.EnchantmentSpecArtifactRequirement{EnergyType: shared.FireEnergy, Limit: 1})
+		oldObj = obj.DeepCopy()
+		validator = EnchantmentCustomValidator{Client: k8sClient}
+		defaulter = EnchantmentCustomDefaulter{}
+	})
+
+	Context("When creating Enchantment under Defaulting Webhook", func() {
+		It("Should fill resourceName, retention and selfReport", func() {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Artifact.Requirements[0].ResourceName).To(Equal(shared.FireEnergy.Resource()))
+			Expect(obj.Spec.Retention.TTLSecondsAfterFinished).NotTo(BeNil())
+			Expect(*obj.Spec.Retention.TTLSecondsAfterFinished).To(Equal(DefaultTTLSecondsAfterFinished))
+			Expect(obj.Spec.SelfReport).NotTo(BeNil())
+			Expect(*obj.Spec.SelfReport).To(BeTrue())
+		})
+
+		It("Should keep values that are already set", func() {
+			ttl, selfReport := 300, false
+			obj.Spec.Retention.TTLSecondsAfterFinished = &ttl
+			obj.Spec.SelfReport = &selfReport
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(*obj.Spec.Retention.TTLSecondsAfterFinished).To(Equal(300))
+			Expect(*obj.Spec.SelfReport).To(BeFalse())
+		})
+	})
+
+	Context("When creating Enchantment under Validating Webhook", func() {
+		BeforeEach(func() {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+		})
+
+		It("Should admit a valid enchantment", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny an enchantment without requirements", func() {
+			obj.Spec.Artifact.Requirements = nil
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.artifact.requirements"))
+		})
+
+		It("Should deny duplicate energy types", func() {
+			obj.Spec.Artifact.Requirements = append(obj.Spec.Artifact.Requirements, obj.Spec.Artifact.Requirements[0])
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.artifact.requirements[1].energyType"))
+		})
+
+		It("Should deny a resourceName that doesn't match the energy type", func() {
+			obj.Spec.Artifact.Requirements[0].ResourceName = shared.FrostEnergy.Resource()
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.artifact.requirements[0].resourceName"))
+		})
+
+		It("Should deny an enchantment that depends on itself", func() {
+			obj.Spec.DependsOn = []enchantmentv1.EnchantmentDependency{{Name: obj.Name}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.dependsOn[0].name"))
+		})
+
+		It("Should deny duplicate dependencies", func() {
+			core := 7
+			obj.Spec.DependsOn = []enchantmentv1.EnchantmentDependency{{ItemID: &core}, {ItemID: &core}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.dependsOn[1]"))
+		})
+	})
+
+	Context("When creating Enchantment that references an EnchanterProfile", func() {
+		BeforeEach(func() {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+		})
+
+		It("Should deny a profile that doesn't exist", func() {
+			obj.Spec.Profile = "missing-profile"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.profile"))
+		})
+
+		It("Should deny a missing manager default when the enchantment names none", func() {
+			validator.DefaultProfile = "missing-default"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		})
+
+		It("Should admit an existing profile", func() {
+			profile := &enchantmentv1.EnchanterProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-profile"},
+				Spec:       enchantmentv1.EnchanterProfileSpec{QueueName: "runesmith-queue"},
+			}
+			Expect(k8sClient.Create(ctx, profile)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, profile)).To(Succeed()) })
+
+			obj.Spec.Profile = profile.Name
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When creating Enchantment that references a Recipe", func() {
+		BeforeEach(func() {
+			obj.Spec.Artifact = enchantmentv1.EnchantmentSpecArtifact{}
+		})
+
+		It("Should deny a recipe that doesn't exist", func() {
+			obj.Spec.Recipe = "missing-recipe"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.recipe"))
+		})
+
+		It("Should deny inline requirements next to a recipe", func() {
+			obj.Spec.Recipe = "ember-blade"
+			obj.Spec.Artifact.Requirements = []enchantmentv1.EnchantmentSpecArtifactRequirement{
+				{EnergyType: shared.FireEnergy, ResourceName: shared.FireEnergy.Resource(), Limit: 1},
+			}
+			validator.Client = nil
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("requirements come from spec.recipe"))
+		})
+
+		It("Should admit an existing recipe without inline requirements", func() {
+			recipe := &enchantmentv1.Recipe{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-recipe"},
+				Spec: enchantmentv1.RecipeSpec{
+					ID:           1,
+					Name:         "Ember Blade",
+					Tier:         shared.Common,
+					Requirements: enchantmentv1.RecipeRequirements{Fire: 2},
+				},
+			}
+			Expect(k8sClient.Create(ctx, recipe)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, recipe)).To(Succeed()) })
+
+			obj.Spec.Recipe = recipe.Name
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When updating Enchantment under Validating Webhook", func() {
+		It("Should admit an update that leaves the spec untouched", func() {
+			obj.Labels = map[string]string{"foo": "bar"}
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny a spec change", func() {
+			obj.Spec.Cost = 42
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec is immutable"))
+		})
+
+		It("Should admit pausing, resuming and cancelling", func() {
+			obj.Spec.Suspend = true
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+
+			oldObj.Spec.Suspend = true
+			obj.Spec.Suspend = false
+			obj.Spec.Cancel = true
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny taking a cancel back", func() {
+			oldObj.Spec.Cancel = true
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("can't be resumed"))
+		})
+	})
+
+	Context("When going through the API server", func() {
+		It("Should default and admit a valid enchantment", func() {
+			ench := newEnchantment("webhook-valid",
+				enchantmentv1.EnchantmentSpecArtifactRequirement{EnergyType: shared.ArcaneEnergy, Limit: 1})
+			Expect(k8sClient.Create(ctx, ench)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, ench)).To(Succeed()) })
+
+			Expect(ench.Spec.Artifact.Requirements[0].ResourceName).To(Equal(shared.ArcaneEnergy.Resource()))
+			Expect(ench.Spec.Retention.TTLSecondsAfterFinished).NotTo(BeNil())
+
+			By("rejecting a later spec change")
+			ench.Spec.Cost = 7
+			Expect(apierrors.IsInvalid(k8sClient.Update(ctx, ench))).To(BeTrue())
+		})
+
+		It("Should reject duplicate energy types", func() {
+			ench := newEnchantment("webhook-duplicate",
+				enchantmentv1.EnchantmentSpecArtifactRequirement{EnergyType: shared.FrostEnergy, Limit: 1},
+				enchantmentv1.EnchantmentSpecArtifactRequirement{EnergyType: shared.FrostEnergy, Limit: 2})
+			Expect(apierrors.IsInvalid(k8sClient.Create(ctx, ench))).To(BeTrue())
+		})
+	})
+})
@@ -71,6 +71,8 @@ type MagicalItem struct {
 	Tier         Tier         `json:"tier"`
 	Requirements Requirements `json:"requirements"`
 	Priority     int          `json:"priority"`
+	// Components are the ids of the items that are forged before this one
+	Components []int `json:"components,omitempty"`
 }
 
 func (i MagicalItem) RequiredList() map[Elemental]int {
@@ -90,14 +92,19 @@ func (i MagicalItem) RequiredList() map[Elemental]int {
 type EnchantmentPhase string
 
 const (
-	ScheduledAS   EnchantmentPhase = "Scheduled"
-	RequeuedAS    EnchantmentPhase = "Requeued"
-	PreemptedAS   EnchantmentPhase = "Preempted"
-	PrioritizedAS EnchantmentPhase = "Prioritized"
-	EnchantingAS  EnchantmentPhase = "Enchanting"
-	CompletedAS   EnchantmentPhase = "Completed"
-	FailedAS      EnchantmentPhase = "Failed"
-	DeletedAS     EnchantmentPhase = "Deleted"
+	ScheduledAS      EnchantmentPhase = "Scheduled"
+	BlockedAS        EnchantmentPhase = "Blocked"
+	QuotaExceededAS  EnchantmentPhase = "QuotaExceeded"
+	WaitingForGangAS EnchantmentPhase = "WaitingForGang"
+	RequeuedAS       EnchantmentPhase = "Requeued"
+	PreemptedAS      EnchantmentPhase = "Preempted"
+	PrioritizedAS    EnchantmentPhase = "Prioritized"
+	EnchantingAS     EnchantmentPhase = "Enchanting"
+	CompletedAS      EnchantmentPhase = "Completed"
+	FailedAS         EnchantmentPhase = "Failed"
+	DeletedAS        EnchantmentPhase = "Deleted"
+	PausedAS         EnchantmentPhase = "Paused"
+	CancelledAS      EnchantmentPhase = "Cancelled"
 )
 
 func (p EnchantmentPhase) String() string {